@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+)
+
+var answerCmd = &cobra.Command{
+	Use:   "answer <[host:]name> [n|text...]",
+	Short: "Answer a numbered menu shown in a session",
+	Long: `Picks an option of the numbered menu a session is showing, such as plan
+approval or a permission dialog. A number picks that option; any other text
+picks the "tell Claude what to change" option and types the text.
+
+Without an answer, prints the menu.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, name := session.ParseLabel(args[0])
+		exec := resolveExecutor(host)
+		fullName := exec.SessionPrefix() + name
+
+		if !exec.HasSession(fullName) {
+			return fmt.Errorf("session %q not found", args[0])
+		}
+
+		if len(args) == 1 {
+			output, err := exec.CapturePaneOutput(fullName, 50)
+			if err != nil {
+				return fmt.Errorf("failed to capture pane: %w", err)
+			}
+			menu := session.ParseMenu(output)
+			if menu == nil {
+				return fmt.Errorf("session %q is not showing a menu", args[0])
+			}
+			if menu.Question != "" {
+				fmt.Println(menu.Question)
+			}
+			for _, o := range menu.Options {
+				fmt.Printf("  %d. %s\n", o.Number, o.Label)
+			}
+			return nil
+		}
+
+		opt, err := session.AnswerMenu(exec, fullName, strings.Join(args[1:], " "))
+		if err != nil {
+			return fmt.Errorf("failed to answer %q: %w", args[0], err)
+		}
+		fmt.Printf("Picked %d. %s\n", opt.Number, opt.Label)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(answerCmd)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/daemon"
+	"github.com/simon/crabctl/internal/state"
+)
+
+const systemdUnit = `[Unit]
+Description=crabctl daemon (autoforward and queued messages for Claude sessions)
+
+[Service]
+ExecStart=%s daemon
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run autoforward and queued messages without the TUI",
+	Long: `Polls sessions in the background and does what the TUI otherwise does
+only while it is open: autoforward, delivering queued messages and saving
+Claude session UUIDs so killed sessions can be resumed.
+
+Runs in the foreground and logs to stderr, which suits a systemd user
+service:
+
+  crabctl daemon --systemd-unit > ~/.config/systemd/user/crabctl.service
+  systemctl --user enable --now crabctl
+
+--detach starts it in the background instead, logging to daemon.log in the
+state directory. Only one daemon runs at a time; while it does, the TUI
+leaves autoforward and queue delivery to it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if unit, _ := cmd.Flags().GetBool("systemd-unit"); unit {
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			fmt.Printf(systemdUnit, exe)
+			return nil
+		}
+		if stop, _ := cmd.Flags().GetBool("stop"); stop {
+			return stopDaemon()
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+		remoteInterval, _ := cmd.Flags().GetDuration("remote-interval")
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			return detachDaemon(interval, remoteInterval)
+		}
+
+		unlock, err := state.LockDaemon()
+		if err != nil {
+			if pid, ok := state.DaemonPID(); ok && errors.Is(err, state.ErrDaemonRunning) {
+				return fmt.Errorf("%w (pid %d)", err, pid)
+			}
+			return err
+		}
+		defer unlock()
+
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state db: %w", err)
+		}
+		defer store.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		logger := log.New(os.Stderr, "", log.LstdFlags)
+		d := daemon.New(buildExecutors(), store, logger)
+		d.Interval = interval
+		d.RemoteInterval = remoteInterval
+		logger.Printf("daemon started (pid %d)", os.Getpid())
+		err = d.Run(ctx)
+		logger.Printf("daemon stopped")
+		return err
+	},
+}
+
+// detachDaemon starts `crabctl daemon` in a new session with its output
+// appended to daemon.log in the state directory.
+func detachDaemon(interval, remoteInterval time.Duration) error {
+	if pid, ok := state.DaemonPID(); ok {
+		return fmt.Errorf("%w (pid %d)", state.ErrDaemonRunning, pid)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	dir, err := state.Dir()
+	if err != nil {
+		return err
+	}
+	logPath := filepath.Join(dir, "daemon.log")
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	c := exec.Command(exe, "daemon", "--interval", interval.String(), "--remote-interval", remoteInterval.String())
+	c.Stdout = logFile
+	c.Stderr = logFile
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	pid := c.Process.Pid
+	_ = c.Process.Release()
+	fmt.Printf("Started daemon (pid %d), logging to %s\n", pid, logPath)
+	return nil
+}
+
+// stopDaemon sends SIGTERM to the running daemon and waits for it to
+// release its lock.
+func stopDaemon() error {
+	pid, ok := state.DaemonPID()
+	if !ok {
+		fmt.Println("No daemon running.")
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop daemon (pid %d): %w", pid, err)
+	}
+	for range 50 {
+		if _, ok := state.DaemonPID(); !ok {
+			fmt.Printf("Stopped daemon (pid %d)\n", pid)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon (pid %d) did not stop", pid)
+}
+
+func init() {
+	daemonCmd.Flags().BoolP("detach", "d", false, "Run in the background, logging to daemon.log in the state directory")
+	daemonCmd.Flags().Bool("stop", false, "Stop the running daemon")
+	daemonCmd.Flags().Bool("systemd-unit", false, "Print a systemd user unit that runs the daemon")
+	daemonCmd.Flags().Duration("interval", 1500*time.Millisecond, "How often to poll local sessions")
+	daemonCmd.Flags().Duration("remote-interval", 5*time.Second, "How often to poll remote hosts")
+	rootCmd.AddCommand(daemonCmd)
+}
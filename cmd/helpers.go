@@ -1,21 +1,10 @@
 package cmd
 
 import (
-	"strings"
-
 	"github.com/simon/crabctl/internal/config"
 	"github.com/simon/crabctl/internal/tmux"
 )
 
-// parseHostName splits "host:name" into (host, name).
-// If no colon, returns ("", name).
-func parseHostName(s string) (host, name string) {
-	if idx := strings.IndexByte(s, ':'); idx >= 0 {
-		return s[:idx], s[idx+1:]
-	}
-	return "", s
-}
-
 // resolveExecutor returns an executor for the given host nickname.
 // Empty host returns a LocalExecutor.
 func resolveExecutor(host string) tmux.Executor {
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [[host:]name]",
+	Short: "Show the recorded events of sessions",
+	Long: `Lists what happened to a session, or to every session: when it was created
+or resumed, each status change and how long the status lasted, messages sent,
+autoforwards and kills.
+
+Status changes are recorded by the TUI or, while it runs, by crabctl daemon,
+so they're missing for stretches when neither was running. Events are kept
+for 30 days unless configured otherwise:
+
+  history:
+    retention_days: 90   # negative keeps events forever`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		var since time.Time
+		if sinceFlag != "" {
+			d, err := parseSince(sinceFlag)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-d)
+		}
+
+		var fullName, host string
+		if len(args) == 1 {
+			var name string
+			host, name = session.ParseLabel(args[0])
+			fullName = resolveExecutor(host).SessionPrefix() + name
+		}
+
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state: %w", err)
+		}
+		defer store.Close()
+		events, err := store.ListEvents(fullName, host, since)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			fmt.Println("No events.")
+			return nil
+		}
+
+		prefixes := sessionPrefixes()
+		spans := make(map[int64]session.Span)
+		for _, sp := range session.Spans(events, time.Now()) {
+			spans[sp.EventID] = sp
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tSESSION\tEVENT\tDETAIL")
+		for _, ev := range events {
+			detail := session.DescribeEvent(ev)
+			if sp, ok := spans[ev.ID]; ok {
+				spent := session.FormatDuration(sp.Duration())
+				if sp.Open {
+					spent += " so far"
+				}
+				detail += " (" + spent + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				ev.Time.Local().Format("2006-01-02 15:04:05"),
+				eventLabel(prefixes, ev.Name, ev.Host), ev.Kind, detail)
+		}
+		return w.Flush()
+	},
+}
+
+// sessionPrefixes maps each configured host to its tmux session prefix.
+func sessionPrefixes() map[string]string {
+	prefixes := make(map[string]string)
+	for _, ex := range buildExecutors() {
+		prefixes[ex.HostName()] = ex.SessionPrefix()
+	}
+	return prefixes
+}
+
+// eventLabel returns "[host:]name" for a session in the history, without
+// its host's tmux prefix.
+func eventLabel(prefixes map[string]string, name, host string) string {
+	return session.Label(host, strings.TrimPrefix(name, prefixes[host]))
+}
+
+func init() {
+	historyCmd.Flags().String("since", "7d", "Only show events from this far back (e.g. 7d, 12h; empty for all)")
+	rootCmd.AddCommand(historyCmd)
+}
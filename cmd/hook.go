@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Record a Claude Code hook event (run by Claude, see: crabctl skill --hooks)",
+	Long: `Reads a Claude Code hook payload from stdin and records it in the state DB,
+keyed by the tmux session Claude runs in. crabctl then prefers these events
+over screen scraping when detecting session status.
+
+Errors are ignored so a broken hook never blocks Claude.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := tmux.CurrentSessionName()
+		if name == "" {
+			return nil
+		}
+
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil
+		}
+		ev, err := session.ParseHookPayload(data)
+		if err != nil {
+			return nil
+		}
+		ev.Name = name
+
+		store, err := state.Open()
+		if err != nil {
+			return nil
+		}
+		defer store.Close()
+		store.RecordHookEvent(ev) //nolint:errcheck
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/simon/crabctl/internal/session"
 	"github.com/simon/crabctl/internal/state"
-	"github.com/simon/crabctl/internal/tmux"
 	"github.com/spf13/cobra"
 )
 
@@ -17,7 +16,7 @@ var killCmd = &cobra.Command{
 	Short: "Kill a Claude session",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		host, name := parseHostName(args[0])
+		host, name := session.ParseLabel(args[0])
 		exec := resolveExecutor(host)
 		fullName := exec.SessionPrefix() + name
 
@@ -26,40 +25,57 @@ var killCmd = &cobra.Command{
 		}
 
 		force, _ := cmd.Flags().GetBool("force")
-		if !force {
-			fmt.Printf("Kill session %q? [y/N] ", args[0])
-			reader := bufio.NewReader(os.Stdin)
-			answer, _ := reader.ReadString('\n')
-			if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
-				fmt.Println("Cancelled.")
-				return nil
-			}
+		reader := bufio.NewReader(os.Stdin)
+		if !force && !confirm(reader, fmt.Sprintf("Kill session %q?", args[0])) {
+			fmt.Println("Cancelled.")
+			return nil
 		}
 
-		// Capture session info before killing
-		workDir := exec.GetPanePath(fullName)
-		paneContent, _ := exec.CapturePaneOutput(fullName, 50)
-		created := tmux.GetSessionCreated(fullName)
-		uuid, firstMsg := session.FindSessionUUID(workDir, created, paneContent, nil)
-
-		if err := exec.KillSession(fullName); err != nil {
-			return fmt.Errorf("failed to kill session: %w", err)
+		store, storeErr := state.Open()
+		if storeErr == nil {
+			defer store.Close()
 		}
 
-		// Record killed session in DB
-		if uuid != "" {
-			if store, err := state.Open(); err == nil {
-				store.MarkKilled(fullName, uuid, workDir, firstMsg)
-				store.Close()
-			}
+		if err := session.Kill(exec, store, fullName); err != nil {
+			return fmt.Errorf("failed to kill session: %w", err)
 		}
 
 		fmt.Printf("Killed session %q\n", args[0])
+
+		// Offer to remove the worktree `crabctl new --worktree` created
+		if storeErr != nil {
+			return nil
+		}
+		wt, ok := store.GetWorktree(fullName, exec.HostName())
+		if !ok {
+			return nil
+		}
+		remove, _ := cmd.Flags().GetBool("remove-worktree")
+		if !remove && !force {
+			remove = confirm(reader, fmt.Sprintf("Remove worktree %s (branch %s is kept)?", wt.Path, wt.Branch))
+		}
+		if !remove {
+			fmt.Printf("Kept worktree %s\n", wt.Path)
+			return nil
+		}
+		if err := session.RemoveWorktree(exec, wt); err != nil {
+			return fmt.Errorf("kept worktree %s: %w", wt.Path, err)
+		}
+		_ = store.DeleteWorktree(fullName, wt.Host)
+		fmt.Printf("Removed worktree %s\n", wt.Path)
 		return nil
 	},
 }
 
+// confirm asks a yes/no question on stdin, defaulting to no.
+func confirm(reader *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := reader.ReadString('\n')
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
 func init() {
-	killCmd.Flags().BoolP("force", "f", false, "Skip confirmation")
+	killCmd.Flags().BoolP("force", "f", false, "Skip confirmation (keeps any worktree)")
+	killCmd.Flags().Bool("remove-worktree", false, "Remove the session's worktree without asking")
 	rootCmd.AddCommand(killCmd)
 }
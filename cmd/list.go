@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List Claude sessions without the TUI",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		statusFilter, _ := cmd.Flags().GetString("status")
+		hostFilter, _ := cmd.Flags().GetString("host")
+
+		switch output {
+		case "table", "json", "tsv":
+		default:
+			return fmt.Errorf("invalid output format %q: use table, json or tsv", output)
+		}
+
+		var wantStatus *session.Status
+		if statusFilter != "" {
+			st, err := session.ParseStatus(statusFilter)
+			if err != nil {
+				return err
+			}
+			wantStatus = &st
+		}
+
+		var filtered []session.Session
+		for _, s := range listAllSessions(buildExecutors()) {
+			if wantStatus != nil && s.Status != *wantStatus {
+				continue
+			}
+			if hostFilter != "" && session.HostLabel(s.Host) != hostFilter {
+				continue
+			}
+			filtered = append(filtered, s)
+		}
+
+		switch output {
+		case "json":
+			return printSessionsJSON(filtered)
+		case "tsv":
+			printSessionsTSV(filtered)
+		default:
+			printSessionsTable(filtered)
+		}
+		return nil
+	},
+}
+
+// listAllSessions queries every executor in parallel and returns the
+// combined, sorted session list. Hosts that fail are reported on stderr.
+func listAllSessions(executors []tmux.Executor) []session.Session {
+	var hooks map[string]state.HookEvent
+	if store, err := state.Open(); err == nil {
+		hooks, _ = store.LoadHookEvents()
+		store.Close()
+	}
+	all, err := session.ListAll(executors, hooks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return all
+}
+
+func printSessionsJSON(sessions []session.Session) error {
+	entries := make([]session.Entry, 0, len(sessions))
+	for _, s := range sessions {
+		entries = append(entries, session.NewEntry(s))
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func printSessionsTSV(sessions []session.Session) {
+	for _, s := range sessions {
+		fields := []string{
+			s.Name,
+			session.HostLabel(s.Host),
+			s.Status.String(),
+			s.Mode,
+			s.LastAction,
+			s.GitChanges,
+			s.PR,
+			s.Context,
+			fmt.Sprintf("%d", int64(s.Duration.Seconds())),
+		}
+		for i, f := range fields {
+			fields[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(f)
+		}
+		fmt.Println(strings.Join(fields, "\t"))
+	}
+}
+
+func printSessionsTable(sessions []session.Session) {
+	if len(sessions) == 0 {
+		fmt.Println("No sessions.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tSTATUS\tMODE\tLAST ACTION\tCHANGES\tPR\tCONTEXT\tDURATION")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name,
+			session.HostLabel(s.Host),
+			s.Status,
+			orDash(s.Mode),
+			orDash(s.LastAction),
+			orDash(s.GitChanges),
+			orDash(s.PR),
+			orDash(s.Context),
+			session.FormatDuration(s.Duration),
+		)
+	}
+	w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	listCmd.Flags().StringP("output", "o", "table", "Output format: table, json or tsv")
+	listCmd.Flags().StringP("status", "s", "", "Only show sessions with this status (e.g. waiting, task-done)")
+	listCmd.Flags().String("host", "", "Only show sessions on this host (\"local\" for this machine)")
+	rootCmd.AddCommand(listCmd)
+}
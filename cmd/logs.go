@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// followHistory is how many lines of scrollback --follow captures at least,
+// for the first dump and each poll. Output that scrolls further than this
+// between polls is only partially shown.
+const followHistory = 200
+
+var logsCmd = &cobra.Command{
+	Use:     "logs <[host:]name>",
+	Aliases: []string{"capture"},
+	Short:   "Print a session's pane output",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, _ := cmd.Flags().GetInt("lines")
+		if n < 0 {
+			return fmt.Errorf("--lines must not be negative")
+		}
+
+		host, name := session.ParseLabel(args[0])
+		exec := resolveExecutor(host)
+		fullName := exec.SessionPrefix() + name
+
+		if !exec.HasSession(fullName) {
+			return fmt.Errorf("session %q not found", args[0])
+		}
+
+		raw, _ := cmd.Flags().GetBool("raw")
+		follow, _ := cmd.Flags().GetBool("follow")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		// Follow polls capture the same window as the first dump, so the
+		// already printed lines are found at the start of the next capture.
+		history := n
+		if follow {
+			history = max(n, followHistory)
+		}
+		lines, err := captureLogLines(exec, fullName, history, raw)
+		if err != nil {
+			return fmt.Errorf("failed to capture pane: %w", err)
+		}
+		if !follow {
+			printLogLines(lastLines(lines, n))
+			return nil
+		}
+
+		// In follow mode, only print output that has settled above the
+		// prompt; the bottom of the pane is redrawn in place by Claude.
+		printed := stableLines(lines)
+		printLogLines(lastLines(printed, n))
+		for {
+			time.Sleep(interval)
+			lines, err := captureLogLines(exec, fullName, history, raw)
+			if err != nil {
+				if !exec.HasSession(fullName) {
+					return fmt.Errorf("session %q ended", args[0])
+				}
+				continue
+			}
+			stable := stableLines(lines)
+			printLogLines(newLogLines(printed, stable))
+			printed = stable
+		}
+	},
+}
+
+// captureLogLines captures and cleans the pane, returning it split into lines.
+func captureLogLines(exec tmux.Executor, fullName string, n int, raw bool) ([]string, error) {
+	var output string
+	var err error
+	if raw {
+		output, err = exec.CapturePaneRaw(fullName, n)
+	} else {
+		output, err = exec.CapturePaneOutput(fullName, n)
+	}
+	if err != nil {
+		return nil, err
+	}
+	output = session.CleanPaneOutput(output)
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// stableLines drops the input prompt and anything below it, plus a live
+// spinner line directly above it, leaving only output that won't change.
+func stableLines(lines []string) []string {
+	end := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(strings.TrimSpace(ansi.Strip(lines[i])), "❯") {
+			end = i
+			break
+		}
+	}
+	for end > 0 {
+		plain := ansi.Strip(lines[end-1])
+		if strings.TrimSpace(plain) != "" && !session.IsRunningIndicator(plain) {
+			break
+		}
+		end--
+	}
+	return lines[:end]
+}
+
+// newLogLines returns the lines of cur that come after the already printed
+// output. It looks for the longest suffix of printed that is a prefix of cur.
+// The last few printed lines may since have been redrawn (e.g. a paragraph
+// still being streamed), so those are dropped and retried before giving up
+// and treating everything in cur as new.
+func newLogLines(printed, cur []string) []string {
+	for drop := 0; drop <= 3 && drop < len(printed); drop++ {
+		p := printed[:len(printed)-drop]
+		maxK := min(len(p), len(cur))
+		for k := maxK; k > 0; k-- {
+			if equalLines(p[len(p)-k:], cur[:k]) {
+				return cur[k:]
+			}
+		}
+	}
+	return cur
+}
+
+// lastLines returns at most the last n lines.
+func lastLines(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+func equalLines(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func printLogLines(lines []string) {
+	for _, l := range lines {
+		fmt.Println(l)
+	}
+}
+
+func init() {
+	logsCmd.Flags().IntP("lines", "n", 50, "Number of lines to show")
+	logsCmd.Flags().Bool("raw", false, "Keep ANSI colors and styling")
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep printing new output as it appears")
+	logsCmd.Flags().Duration("interval", time.Second, "Polling interval for --follow")
+	rootCmd.AddCommand(logsCmd)
+}
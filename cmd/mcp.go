@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/mcp"
+	"github.com/simon/crabctl/internal/state"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve crabctl as an MCP server over stdio",
+	Long: `Runs a Model Context Protocol server on stdin/stdout so a coordinating
+Claude can manage crabs as tools with structured results: list_sessions,
+new_session, send_message, wait_for_status, read_transcript, answer_prompt
+and kill_session.
+
+Register it with Claude Code with "crabctl skill --mcp", or by hand:
+
+  claude mcp add --scope user crabctl -- crabctl mcp`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state db: %w", err)
+		}
+		defer store.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		return mcp.New(buildExecutors(), store, rootCmd.Version).Serve(ctx, os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -3,61 +3,107 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
-	"time"
 
 	"github.com/simon/crabctl/internal/session"
-	"github.com/simon/crabctl/internal/tmux"
+	"github.com/simon/crabctl/internal/state"
 	"github.com/spf13/cobra"
 )
 
-var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+// worktreeBranchDefault is --worktree's value when given without a branch:
+// the branch is named after the session.
+const worktreeBranchDefault = "<name>"
 
 var newCmd = &cobra.Command{
 	Use:   "new <[host:]name> [message...]",
 	Short: "Create a new Claude session",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Creates a tmux session running Claude.
+
+Claude starts in bypass permission mode unless --permission-mode or the
+config file says otherwise. Defaults can be set for all hosts and per host
+in ~/.config/crabctl/config.yaml:
+
+  claude:
+    permission_mode: acceptEdits
+    model: opus
+  hosts:
+    bay3:
+      host: bay3.example.com
+      claude:
+        bin: /opt/claude/bin/claude
+        args: ["--verbose"]
+
+Templates bundle a host, directory, claude settings, an initial message and
+autoforward under a name. The message may use {{.Name}} (the session name)
+and {{.Arg}} (the text after the name):
+
+  templates:
+    reviewer:
+      dir: ~/src/app
+      claude:
+        permission_mode: plan
+      message: "Review the PR on branch {{.Name}}. {{.Arg}}"
+      autoforward: true
+
+--worktree gives the session its own git worktree of the --dir repo, under
+worktree_root (default ~/.local/share/crabctl/worktrees), so crabs in the
+same repo don't trample each other. The branch defaults to the session name
+and is created from HEAD if it doesn't exist. crabctl kill offers to remove
+the worktree again.`,
+	Example: `  crabctl new fix-login -m "Fix the login redirect loop"
+  crabctl new --template reviewer my-pr-123 focus on the migration
+  crabctl new --worktree fix-login -c ~/src/app
+  crabctl new --worktree=feature/login fix-login`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		host, name := parseHostName(args[0])
-		if !validName.MatchString(name) {
-			return fmt.Errorf("invalid name %q: use only alphanumeric, hyphens, underscores", name)
+		host, name := session.ParseLabel(args[0])
+		if host == "" && strings.Contains(args[0], ":") {
+			host = "local" // ":name" overrides the template's host
 		}
-
-		exec := resolveExecutor(host)
-		fullName := exec.SessionPrefix() + name
-		if exec.HasSession(fullName) {
-			return fmt.Errorf("session %q already exists", args[0])
-		}
-
-		dir, _ := cmd.Flags().GetString("dir")
-		if dir == "" && host == "" {
-			dir, _ = os.Getwd()
+		opts := session.CreateOptions{Name: name, Host: host}
+		opts.Dir, _ = cmd.Flags().GetString("dir")
+		opts.Template, _ = cmd.Flags().GetString("template")
+		if opts.Worktree = cmd.Flags().Changed("worktree"); opts.Worktree {
+			opts.WorktreeBranch, _ = cmd.Flags().GetString("worktree")
+			if opts.WorktreeBranch == worktreeBranchDefault {
+				opts.WorktreeBranch = ""
+			}
 		}
 		attach, _ := cmd.Flags().GetBool("attach")
 
-		// Collect message from remaining args or -m flag
-		msgFlag, _ := cmd.Flags().GetString("message")
-		message := msgFlag
-		if message == "" && len(args) > 1 {
-			message = strings.Join(args[1:], " ")
+		// Collect message from remaining args or -m flag; a template's
+		// message gets it as {{.Arg}}
+		opts.Message, _ = cmd.Flags().GetString("message")
+		if opts.Message == "" && len(args) > 1 {
+			opts.Message = strings.Join(args[1:], " ")
 		}
 
-		var claudeArgs []string
-		claudeArgs = append(claudeArgs, "--dangerously-skip-permissions")
+		opts.Claude.PermissionMode, _ = cmd.Flags().GetString("permission-mode")
+		opts.Claude.Model, _ = cmd.Flags().GetString("model")
+		opts.Claude.Args, _ = cmd.Flags().GetStringArray("claude-arg")
+		opts.Claude.Bin, _ = cmd.Flags().GetString("claude-bin")
 
-		if err := exec.NewSession(name, dir, claudeArgs); err != nil {
-			return fmt.Errorf("failed to create session: %w", err)
+		store, err := state.Open()
+		if err == nil {
+			defer store.Close()
+		}
+		created, err := session.Create(buildExecutors(), store, opts)
+		if err != nil {
+			return err
 		}
+		exec, fullName := created.Executor, created.FullName
 
-		fmt.Printf("Created session %q\n", args[0])
+		if wt := created.Worktree; wt != nil {
+			fmt.Printf("Created worktree %s on branch %s\n", wt.Path, wt.Branch)
+		}
+		fmt.Printf("Created session %q\n", session.Label(exec.HostName(), name))
 
-		if message != "" {
-			if err := waitForPrompt(exec, fullName); err != nil {
+		if message := created.Message; message != "" {
+			if err := session.WaitForPrompt(exec, fullName); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: %v (session created but message not sent)\n", err)
 				return nil
 			}
-			if err := sendMessage(exec, fullName, message); err != nil {
+			if err := session.SendMessage(exec, fullName, message); err != nil {
 				return fmt.Errorf("failed to send message: %w", err)
 			}
 			fmt.Printf("Sent: %s\n", message)
@@ -71,58 +117,16 @@ var newCmd = &cobra.Command{
 	},
 }
 
-type promptDetector interface {
-	CapturePaneOutput(string, int) (string, error)
-	SendKeys(string, string) error
-}
-
-// waitForPrompt polls the pane until Claude shows the ❯ prompt.
-func waitForPrompt(exec promptDetector, fullName string) error {
-	timeout := 30 * time.Second
-	poll := 500 * time.Millisecond
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		time.Sleep(poll)
-		output, err := exec.CapturePaneOutput(fullName, 10)
-		if err != nil {
-			continue
-		}
-		status := session.DetectStatus(output)
-		if status == session.Waiting {
-			return nil
-		}
-	}
-	return fmt.Errorf("timed out waiting for Claude prompt (%v)", timeout)
-}
-
-// sendMessage sends a message and verifies Claude started processing it.
-// Retries the Enter key if Claude is still waiting after sending.
-func sendMessage(exec promptDetector, fullName, message string) error {
-	if err := exec.SendKeys(fullName, message); err != nil {
-		return err
-	}
-
-	// Verify Claude started processing (transitioned away from Waiting)
-	for i := 0; i < 3; i++ {
-		time.Sleep(500 * time.Millisecond)
-		output, err := exec.CapturePaneOutput(fullName, 10)
-		if err != nil {
-			continue
-		}
-		status := session.DetectStatus(output)
-		if status != session.Waiting {
-			return nil // Claude is processing
-		}
-		// Still waiting — the Enter key might have been lost, resend just Enter
-		tmux.SendEnter(fullName)
-	}
-	return nil // sent text, best effort
-}
-
 func init() {
 	newCmd.Flags().StringP("dir", "c", "", "Working directory for the session")
 	newCmd.Flags().StringP("message", "m", "", "Message to send once Claude is ready")
 	newCmd.Flags().BoolP("attach", "a", false, "Attach to the session immediately")
+	newCmd.Flags().StringP("template", "t", "", "Start from a template in the config file")
+	newCmd.Flags().String("worktree", "", "Start in a new git worktree of the --dir repo, on this branch (--worktree=branch)")
+	newCmd.Flags().Lookup("worktree").NoOptDefVal = worktreeBranchDefault
+	newCmd.Flags().String("permission-mode", "", "Claude permission mode: default, plan, acceptEdits or bypass")
+	newCmd.Flags().String("model", "", "Claude model, e.g. opus or sonnet")
+	newCmd.Flags().StringArray("claude-arg", nil, "Extra argument passed to claude (repeatable)")
+	newCmd.Flags().String("claude-bin", "", "Claude binary to run instead of claude")
 	rootCmd.AddCommand(newCmd)
 }
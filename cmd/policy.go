@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy [Tool(argument)]",
+	Short: "Show auto-approval decisions, or test the policy against a call",
+	Long: `While the TUI runs, permission prompts matching a rule in
+~/.config/crabctl/policy.yaml are answered automatically. Deny rules win
+over allow rules. Bash commands chaining several commands (;, &&, |, ...)
+and calls spanning several lines on screen are never auto-approved.
+
+  allow:
+    - Bash(go test*)
+    - Read
+  deny:
+    - Bash(rm -rf*)
+
+Without arguments, lists the most recent decisions. With a call such as
+'Bash(go test ./...)', prints what the policy would do with it.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy, err := session.LoadPolicy()
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", config.PolicyPath(), err)
+		}
+
+		if len(args) == 1 {
+			tool, arg, _ := strings.Cut(args[0], "(")
+			prompt := &session.PermissionPrompt{Tool: tool, Argument: strings.TrimSuffix(arg, ")")}
+			action, rule := policy.Decide(prompt)
+			if action == session.PolicyNone {
+				fmt.Println("ask (no rule matches)")
+				return nil
+			}
+			fmt.Printf("%s (rule %s)\n", action, rule)
+			return nil
+		}
+
+		if policy == nil {
+			fmt.Fprintf(os.Stderr, "No policy at %s; every prompt is left to you.\n", config.PolicyPath())
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state: %w", err)
+		}
+		defer store.Close()
+		decisions, err := store.ListPolicyDecisions(limit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tSESSION\tACTION\tCALL\tRULE")
+		for _, d := range decisions {
+			action := d.Action
+			if d.Error != "" {
+				action += " (failed: " + d.Error + ")"
+			}
+			call := (&session.PermissionPrompt{Tool: d.Tool, Argument: d.Argument}).Summary()
+			name := session.Label(d.Host, d.Name)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				d.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				name, action, call, d.Rule)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	policyCmd.Flags().IntP("limit", "n", 20, "number of decisions to show")
+	rootCmd.AddCommand(policyCmd)
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume [name|uuid|text]",
+	Short: "Resume a killed or crashed Claude session",
+	Long: `Relaunches a past session with claude --resume in its original directory.
+
+The session is picked by crab name, session UUID prefix, or a case-insensitive
+match against its first message. Without an argument the most recent session
+is resumed.
+
+With --all, every recent Claude conversation in ~/.claude/projects is
+considered, including ones crabctl never managed; those are adopted as a
+new crab named after their project directory unless --name is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		all, _ := cmd.Flags().GetBool("all")
+		newName, _ := cmd.Flags().GetString("name")
+		host, _ := cmd.Flags().GetString("host")
+		attach, _ := cmd.Flags().GetBool("attach")
+
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state db: %w", err)
+		}
+		defer store.Close()
+
+		past, err := session.LoadResumable(store, buildExecutors(), all)
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		if list {
+			printResumable(past)
+			return nil
+		}
+
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		matches := matchResumable(past, query)
+		if len(matches) == 0 {
+			return fmt.Errorf("no resumable session matches %q", query)
+		}
+		if len(matches) > 1 && query != "" {
+			printResumable(matches)
+			return fmt.Errorf("%d sessions match %q, be more specific", len(matches), query)
+		}
+		cs := matches[0]
+
+		name := newName
+		if name == "" {
+			name = session.SuggestName(cs, resolveExecutor(cs.Host).SessionPrefix())
+		}
+		if !session.ValidName(name) {
+			return fmt.Errorf("invalid name %q: use only alphanumeric, hyphens, underscores", name)
+		}
+
+		// Resume where the conversation's session file lives unless told otherwise
+		if !cmd.Flags().Changed("host") {
+			host = cs.Host
+		}
+		exec := resolveExecutor(host)
+		fullName := exec.SessionPrefix() + name
+		if exec.HasSession(fullName) {
+			return fmt.Errorf("session %q already exists (use --name to pick another)", name)
+		}
+
+		claudeBin, claudeArgs, err := session.ResumeLaunchArgs(cs)
+		if err != nil {
+			return err
+		}
+		if err := exec.NewSession(name, cs.ProjectDir, claudeBin, claudeArgs); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		_ = store.RecordEvent(state.Event{Name: fullName, Host: host, Kind: state.EventResume, Detail: cs.UUID, WorkDir: cs.ProjectDir})
+
+		label := session.Label(host, name)
+		fmt.Printf("Resumed %s as %q in %s\n", cs.UUID, label, cs.ProjectDir)
+
+		if attach {
+			return exec.AttachSession(fullName)
+		}
+		return nil
+	},
+}
+
+// matchResumable picks sessions for a query, trying in order: exact crab
+// name (with or without prefix), UUID prefix, then first message substring.
+// An empty query returns the most recent session.
+func matchResumable(past []session.ClaudeSession, query string) []session.ClaudeSession {
+	if query == "" {
+		if len(past) == 0 {
+			return nil
+		}
+		return past[:1]
+	}
+
+	for _, cs := range past {
+		if cs.Name != "" && (cs.Name == query || crabName(cs) == query) {
+			return []session.ClaudeSession{cs}
+		}
+	}
+
+	var byUUID []session.ClaudeSession
+	for _, cs := range past {
+		if strings.HasPrefix(cs.UUID, query) {
+			byUUID = append(byUUID, cs)
+		}
+	}
+	if len(byUUID) > 0 {
+		return byUUID
+	}
+
+	lower := strings.ToLower(query)
+	var byMsg []session.ClaudeSession
+	for _, cs := range past {
+		if strings.Contains(strings.ToLower(cs.FirstMessage), lower) {
+			byMsg = append(byMsg, cs)
+		}
+	}
+	return byMsg
+}
+
+func printResumable(past []session.ClaudeSession) {
+	if len(past) == 0 {
+		fmt.Println("No resumable sessions.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGO\tHOST\tNAME\tUUID\tSTATE\tDIR\tMESSAGE")
+	for _, cs := range past {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			session.FormatDurationCoarse(time.Since(cs.ModTime)),
+			session.HostLabel(cs.Host),
+			orDash(crabName(cs)),
+			shortUUID(cs.UUID),
+			cs.State(),
+			cs.ProjectDir,
+			cs.FirstMessage,
+		)
+	}
+	w.Flush()
+}
+
+// crabName returns a past session's name without its host's session prefix.
+func crabName(cs session.ClaudeSession) string {
+	return strings.TrimPrefix(cs.Name, resolveExecutor(cs.Host).SessionPrefix())
+}
+
+// shortUUID returns the first block of a UUID, enough to pass back to resume.
+func shortUUID(uuid string) string {
+	if idx := strings.IndexByte(uuid, '-'); idx > 0 {
+		return uuid[:idx]
+	}
+	return uuid
+}
+
+func init() {
+	resumeCmd.Flags().BoolP("list", "l", false, "List resumable sessions")
+	resumeCmd.Flags().Bool("all", false, "Include every recent Claude conversation on disk, not just crabs")
+	resumeCmd.Flags().StringP("name", "n", "", "Name for the resumed session (default: original name)")
+	resumeCmd.Flags().String("host", "", "Host to resume on (default: the host the session ran on)")
+	resumeCmd.Flags().BoolP("attach", "a", false, "Attach to the session immediately")
+	rootCmd.AddCommand(resumeCmd)
+}
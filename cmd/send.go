@@ -5,14 +5,21 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
 )
 
 var sendCmd = &cobra.Command{
 	Use:   "send <[host:]name> <text...>",
 	Short: "Send text to a Claude session",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Types text into a Claude session and presses Enter.
+
+With --queue the text is stored instead and sent once the session is idle
+(waiting for input), one message per idle turn, by the running crabctl TUI.`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		host, name := parseHostName(args[0])
+		host, name := session.ParseLabel(args[0])
 		text := strings.Join(args[1:], " ")
 		exec := resolveExecutor(host)
 		fullName := exec.SessionPrefix() + name
@@ -21,9 +28,27 @@ var sendCmd = &cobra.Command{
 			return fmt.Errorf("session %q not found", args[0])
 		}
 
+		if queue, _ := cmd.Flags().GetBool("queue"); queue {
+			store, err := state.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open state: %w", err)
+			}
+			defer store.Close()
+			n, err := store.EnqueueMessage(fullName, host, text)
+			if err != nil {
+				return fmt.Errorf("failed to queue: %w", err)
+			}
+			fmt.Printf("Queued for %q (%d pending): %s\n", args[0], n, text)
+			return nil
+		}
+
 		if err := exec.SendKeys(fullName, text); err != nil {
 			return fmt.Errorf("failed to send: %w", err)
 		}
+		if store, err := state.Open(); err == nil {
+			_ = store.RecordEvent(state.Event{Name: fullName, Host: host, Kind: state.EventSend, Detail: text})
+			store.Close()
+		}
 
 		fmt.Printf("Sent to %q: %s\n", args[0], text)
 		return nil
@@ -31,5 +56,6 @@ var sendCmd = &cobra.Command{
 }
 
 func init() {
+	sendCmd.Flags().BoolP("queue", "q", false, "Queue the text until the session is idle")
 	rootCmd.AddCommand(sendCmd)
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/api"
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/state"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an HTTP/JSON API for controlling sessions",
+	Long: `Serves an HTTP/JSON API so dashboards and editor plugins can manage crabs
+without shelling out to crabctl:
+
+  GET    /sessions                   list sessions
+  POST   /sessions                   create one: {"name", "host", "dir", "message",
+                                     "permission_mode", "model", "claude_args", "autoforward"}
+  GET    /sessions/{id}?lines=N      one session with a pane preview
+  DELETE /sessions/{id}              kill it
+  POST   /sessions/{id}/send         {"text", "queue"}
+  PUT    /sessions/{id}/autoforward  {"enabled"}
+  GET    /resumable?all=1            past sessions that can be resumed
+  POST   /resumable/{uuid}/resume    {"name", "host"}, both optional
+  GET    /events                     server-sent "status" and "gone" events
+
+A session {id} is its name, prefixed with "host:" for remote hosts. Errors
+come back as {"error": "..."}.
+
+By default the API listens on a unix socket in the state directory, only
+accessible to you. TCP listeners require a token in the config file, sent
+as "Authorization: Bearer <token>" (or ?token= for EventSource):
+
+  api:
+    token: some-long-random-string`,
+	Example: `  crabctl serve
+  crabctl serve --listen 127.0.0.1:7077
+  curl --unix-socket ~/.local/state/crabctl/crabctl.sock http://crabctl/sessions`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("listen")
+		if addr == "" {
+			dir, err := state.Dir()
+			if err != nil {
+				return err
+			}
+			addr = "unix://" + filepath.Join(dir, "crabctl.sock")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		ln, err := listen(addr, cfg.API.Token)
+		if err != nil {
+			return err
+		}
+
+		store, err := state.Open()
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to open state db: %w", err)
+		}
+		defer store.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		// Sockets are private to the user, so only TCP needs the token
+		token := cfg.API.Token
+		if strings.HasPrefix(addr, "unix://") {
+			token = ""
+		}
+		s := api.New(buildExecutors(), store, token)
+		s.EventInterval, _ = cmd.Flags().GetDuration("event-interval")
+		srv := &http.Server{
+			Handler:     s.Handler(),
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+			defer done()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Fprintf(os.Stderr, "Serving the crabctl API on %s\n", addr)
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// listen opens a unix socket for "unix://<path>" addresses and a TCP
+// listener otherwise. TCP needs a token; sockets are made private to the
+// user instead.
+func listen(addr, token string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		if token == "" {
+			return nil, fmt.Errorf("listening on TCP needs a token: set api.token in %s", filepath.Join(config.Dir(), "config.yaml"))
+		}
+		return net.Listen("tcp", addr)
+	}
+
+	// Replace a socket left behind by a server that didn't shut down
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", path)
+		}
+		os.Remove(path)
+	}
+	// Bind under a umask that leaves the socket 0600 from the start, rather
+	// than chmodding it once others may already have connected
+	umask := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	return ln, err
+}
+
+func init() {
+	serveCmd.Flags().StringP("listen", "l", "", "unix:///path/to.sock or host:port (default: crabctl.sock in the state directory)")
+	serveCmd.Flags().Duration("event-interval", 2*time.Second, "How often /events polls for status changes")
+	rootCmd.AddCommand(serveCmd)
+}
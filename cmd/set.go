@@ -5,15 +5,40 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
 	"github.com/simon/crabctl/internal/state"
 )
 
 var setCmd = &cobra.Command{
 	Use:   "set <[host:]name>",
 	Short: "Set session options (e.g. autoforward)",
-	Args:  cobra.ExactArgs(1),
+	Long: fmt.Sprintf(`Sets session options.
+
+Autoforward sends a message to a session that has been waiting for input,
+up to a number of times in a row. The --af-* flags set this session's
+policy; anything not set falls back to the "autoforward" section of
+~/.config/crabctl/config.yaml and then to the built-in defaults:
+
+  autoforward:
+    message: %q
+    delay: %s
+    max: %d
+    stop_on_task_done: %t
+
+--mute stops status notifications (the "notify" section of the config) for
+this session; --unmute turns them back on.`,
+		session.DefaultAutoForward.Message,
+		session.DefaultAutoForward.Delay,
+		session.DefaultAutoForward.Max,
+		session.DefaultAutoForward.StopsOnTaskDone()),
+	Example: `  crabctl set -a my-crab --af-delay 1m --af-max 20
+  crabctl set my-crab --af-message "Keep going, run the tests when done"
+  crabctl set my-crab --af-reset
+  crabctl set my-crab --mute`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		host, name := parseHostName(args[0])
+		host, name := session.ParseLabel(args[0])
 		exec := resolveExecutor(host)
 		fullName := exec.SessionPrefix() + name
 
@@ -23,9 +48,19 @@ var setCmd = &cobra.Command{
 
 		af, _ := cmd.Flags().GetBool("autoforward")
 		stopAf, _ := cmd.Flags().GetBool("stop-autoforward")
+		reset, _ := cmd.Flags().GetBool("af-reset")
+		mute, _ := cmd.Flags().GetBool("mute")
+		unmute, _ := cmd.Flags().GetBool("unmute")
+		policyChanged := reset
+		for _, f := range []string{"af-message", "af-delay", "af-max", "af-stop-on-done"} {
+			policyChanged = policyChanged || cmd.Flags().Changed(f)
+		}
 
-		if !af && !stopAf {
-			return fmt.Errorf("specify -a/--autoforward or -A/--stop-autoforward")
+		if !af && !stopAf && !policyChanged && !mute && !unmute {
+			return fmt.Errorf("specify -a/--autoforward, -A/--stop-autoforward, --mute, --unmute or an --af-* option")
+		}
+		if mute && unmute {
+			return fmt.Errorf("--mute and --unmute are mutually exclusive")
 		}
 
 		store, err := state.Open()
@@ -34,20 +69,69 @@ var setCmd = &cobra.Command{
 		}
 		defer store.Close()
 
+		if policyChanged {
+			policies, err := store.LoadAutoForwardPolicies()
+			if err != nil {
+				return fmt.Errorf("failed to load autoforward policy: %w", err)
+			}
+			policy := policies[state.QueueKey(exec.HostName(), fullName)]
+			if reset {
+				policy = config.AutoForwardConfig{}
+			}
+			if cmd.Flags().Changed("af-message") {
+				policy.Message, _ = cmd.Flags().GetString("af-message")
+			}
+			if cmd.Flags().Changed("af-delay") {
+				if policy.Delay, _ = cmd.Flags().GetDuration("af-delay"); policy.Delay <= 0 {
+					return fmt.Errorf("--af-delay must be positive")
+				}
+			}
+			if cmd.Flags().Changed("af-max") {
+				if policy.Max, _ = cmd.Flags().GetInt("af-max"); policy.Max <= 0 {
+					return fmt.Errorf("--af-max must be positive")
+				}
+			}
+			if cmd.Flags().Changed("af-stop-on-done") {
+				stop, _ := cmd.Flags().GetBool("af-stop-on-done")
+				policy.StopOnTaskDone = &stop
+			}
+			if err := store.SetAutoForwardPolicy(fullName, exec.HostName(), policy); err != nil {
+				return fmt.Errorf("failed to set autoforward policy: %w", err)
+			}
+			p := session.LoadAutoForwardDefaults().Merge(policy)
+			stops := "stops"
+			if !p.StopsOnTaskDone() {
+				stops = "keeps going"
+			}
+			fmt.Printf("Autoforward for %q: after %s, up to %d times, %s on TASK DONE\n", args[0], p.Delay, p.Max, stops)
+			fmt.Printf("  message: %s\n", p.Message)
+		}
+
 		if af {
-			if err := store.SetAutoForward(fullName, true); err != nil {
+			if err := store.SetAutoForward(fullName, exec.HostName(), true); err != nil {
 				return fmt.Errorf("failed to set autoforward: %w", err)
 			}
 			fmt.Printf("Enabled autoforward for %q\n", args[0])
 		}
 
 		if stopAf {
-			if err := store.SetAutoForward(fullName, false); err != nil {
+			if err := store.SetAutoForward(fullName, exec.HostName(), false); err != nil {
 				return fmt.Errorf("failed to disable autoforward: %w", err)
 			}
 			fmt.Printf("Disabled autoforward for %q\n", args[0])
 		}
 
+		if mute || unmute {
+			if err := store.SetMuted(fullName, exec.HostName(), mute); err != nil {
+				return fmt.Errorf("failed to set mute: %w", err)
+			}
+			if mute {
+				fmt.Printf("Muted notifications for %q\n", args[0])
+			} else {
+				fmt.Printf("Unmuted notifications for %q\n", args[0])
+			}
+		}
+
 		return nil
 	},
 }
@@ -55,5 +139,12 @@ var setCmd = &cobra.Command{
 func init() {
 	setCmd.Flags().BoolP("autoforward", "a", false, "Enable autoforward")
 	setCmd.Flags().BoolP("stop-autoforward", "A", false, "Disable autoforward")
+	setCmd.Flags().String("af-message", "", "Message autoforward sends (empty = default)")
+	setCmd.Flags().Duration("af-delay", 0, "How long the session must wait before a forward, e.g. 30s")
+	setCmd.Flags().Int("af-max", 0, "Forwards in a row before giving up until the session runs again")
+	setCmd.Flags().Bool("af-stop-on-done", true, "Stop forwarding once the session says TASK DONE")
+	setCmd.Flags().Bool("af-reset", false, "Clear this session's autoforward policy before applying other --af-* flags")
+	setCmd.Flags().Bool("mute", false, "Mute status notifications for this session")
+	setCmd.Flags().Bool("unmute", false, "Unmute status notifications for this session")
 	rootCmd.AddCommand(setCmd)
 }
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
 )
 
 var embeddedSkillContent []byte
@@ -17,7 +21,14 @@ func SetSkillContent(content []byte) {
 var skillCmd = &cobra.Command{
 	Use:   "skill",
 	Short: "Install the crab skill for Claude Code",
-	Long:  `Installs the crab skill globally to ~/.claude/skills/crab/SKILL.md.`,
+	Long: `Installs the crab skill globally to ~/.claude/skills/crab/SKILL.md.
+
+With --hooks, also registers "crabctl hook" for Claude Code's hook events in
+~/.claude/settings.json so session status is pushed by Claude instead of
+scraped from the screen.
+
+With --mcp, also registers "crabctl mcp" as a user-scoped MCP server in
+~/.claude.json so Claude can manage crabs as tools.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(embeddedSkillContent) == 0 {
 			return fmt.Errorf("skill content not embedded (build with make)")
@@ -39,10 +50,184 @@ var skillCmd = &cobra.Command{
 			return fmt.Errorf("failed to write %s: %w", skillPath, err)
 		}
 		fmt.Printf("Installed %s\n", skillPath)
+
+		if hooks, _ := cmd.Flags().GetBool("hooks"); hooks {
+			settingsPath := filepath.Join(home, ".claude", "settings.json")
+			added, err := installHooks(settingsPath)
+			if err != nil {
+				return fmt.Errorf("failed to install hooks: %w", err)
+			}
+			if added == 0 {
+				fmt.Printf("Hooks already installed in %s\n", settingsPath)
+			} else {
+				fmt.Printf("Installed %d hooks in %s\n", added, settingsPath)
+			}
+		}
+
+		if addMCP, _ := cmd.Flags().GetBool("mcp"); addMCP {
+			configPath := filepath.Join(home, ".claude.json")
+			added, err := installMCPServer(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to register MCP server: %w", err)
+			}
+			if added {
+				fmt.Printf("Registered the crabctl MCP server in %s\n", configPath)
+			} else {
+				fmt.Printf("MCP server already registered in %s\n", configPath)
+			}
+		} else {
+			fmt.Println("Run with --mcp to also register crabctl as an MCP server for Claude Code.")
+		}
 		return nil
 	},
 }
 
+// installMCPServer adds "crabctl mcp" to the mcpServers of a Claude Code
+// config file, keeping everything else. Returns false if a crabctl server
+// is already registered.
+func installMCPServer(configPath string) (bool, error) {
+	settings := make(map[string]interface{})
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return false, fmt.Errorf("parse %s: %w", configPath, err)
+		}
+	}
+
+	servers, _ := settings["mcpServers"].(map[string]interface{})
+	if servers == nil {
+		servers = make(map[string]interface{})
+	}
+	if _, ok := servers["crabctl"]; ok {
+		return false, nil
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		bin = "crabctl"
+	}
+	servers["crabctl"] = map[string]interface{}{
+		"type":    "stdio",
+		"command": bin,
+		"args":    []interface{}{"mcp"},
+	}
+	settings["mcpServers"] = servers
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, writeFileAtomic(configPath, append(out, '\n'), 0o600)
+}
+
+// installHooks registers "crabctl hook" for every event in session.HookEvents
+// in a Claude Code settings file, keeping all other settings. Events that
+// already run a crabctl hook are left alone. Returns the number added.
+func installHooks(settingsPath string) (int, error) {
+	settings := make(map[string]interface{})
+	data, err := os.ReadFile(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return 0, fmt.Errorf("parse %s: %w", settingsPath, err)
+		}
+	}
+
+	bin, err := os.Executable()
+	if err != nil {
+		bin = "crabctl"
+	}
+	command := bin + " hook"
+
+	hooks, _ := settings["hooks"].(map[string]interface{})
+	if hooks == nil {
+		hooks = make(map[string]interface{})
+	}
+
+	added := 0
+	for _, event := range session.HookEvents {
+		groups, _ := hooks[event].([]interface{})
+		if hasCrabctlHook(groups) {
+			continue
+		}
+		group := map[string]interface{}{
+			"hooks": []interface{}{
+				map[string]interface{}{"type": "command", "command": command},
+			},
+		}
+		if event == "PreToolUse" || event == "PostToolUse" {
+			group["matcher"] = "*"
+		}
+		hooks[event] = append(groups, group)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	settings["hooks"] = hooks
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0o755); err != nil {
+		return 0, err
+	}
+	return added, writeFileAtomic(settingsPath, append(out, '\n'), 0o644)
+}
+
+// writeFileAtomic replaces a file via a temp file and rename. Claude Code
+// rewrites its config files while running, so a partly written file could
+// otherwise be read back or overwritten. Symlinked files are written through
+// and an existing file keeps its mode; perm is for a new file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// hasCrabctlHook reports whether any matcher group runs "crabctl hook".
+func hasCrabctlHook(groups []interface{}) bool {
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		entries, _ := group["hooks"].([]interface{})
+		for _, e := range entries {
+			entry, _ := e.(map[string]interface{})
+			command, _ := entry["command"].(string)
+			if strings.Contains(command, "crabctl") && strings.HasSuffix(command, " hook") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func init() {
+	skillCmd.Flags().Bool("hooks", false, "Also register crabctl hooks in ~/.claude/settings.json")
+	skillCmd.Flags().Bool("mcp", false, "Also register crabctl as an MCP server in ~/.claude.json")
 	rootCmd.AddCommand(skillCmd)
 }
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+// statsRow is the machine-readable form of a stats group for JSON and CSV
+// output. Times are in seconds.
+type statsRow struct {
+	Key          string `json:"key"`
+	Sessions     int    `json:"sessions"`
+	Running      int64  `json:"running_seconds"`
+	Waiting      int64  `json:"waiting_seconds"`
+	Permission   int64  `json:"permission_seconds"`
+	StalledPct   int    `json:"stalled_percent"`
+	AutoForwards int    `json:"autoforwards"`
+	Sends        int    `json:"sends"`
+	Done         int    `json:"done"`
+	AvgToDone    int64  `json:"avg_seconds_to_done"`
+
+	stats session.StatusStats
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show where sessions spent their time",
+	Long: `Sums the event history per session or per repo: time running, waiting for
+input and blocked on permission prompts, how much of it was stalled (waiting
+or blocked), autoforwards and messages sent, and how long sessions took from
+start to TASK DONE on average. A repo is the top of a session's git repo, so
+sessions in subdirectories and in worktrees made by crabctl new --worktree
+count toward the repo they came from.
+
+Status times come from the history recorded by the TUI or crabctl daemon, so
+they only cover stretches when one of them was running. A session that went
+while neither was running counts until its last recorded event.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		by, _ := cmd.Flags().GetString("by")
+		format, _ := cmd.Flags().GetString("format")
+
+		switch by {
+		case "session", "repo":
+		default:
+			return fmt.Errorf("invalid grouping %q: use session or repo", by)
+		}
+		switch format {
+		case "table", "json", "csv":
+		default:
+			return fmt.Errorf("invalid format %q: use table, json or csv", format)
+		}
+
+		now := time.Now()
+		var since time.Time
+		if sinceFlag != "" {
+			d, err := parseSince(sinceFlag)
+			if err != nil {
+				return err
+			}
+			since = now.Add(-d)
+		}
+
+		// Sessions that went unseen only count until their last event
+		live := listAllSessions(buildExecutors())
+
+		store, err := state.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open state: %w", err)
+		}
+		defer store.Close()
+		// Statuses entered before since still count from since on
+		events, err := store.ListEvents("", "", time.Time{})
+		if err != nil {
+			return err
+		}
+
+		prefixes := sessionPrefixes()
+		groups := session.GroupStatsBy(session.ComputeStats(events, live, since, now), func(s session.SessionStats) string {
+			if by == "repo" {
+				return repoLabel(s.Host, s.Repo())
+			}
+			return eventLabel(prefixes, s.Name, s.Host)
+		})
+		rows := make([]statsRow, 0, len(groups))
+		for _, g := range groups {
+			rows = append(rows, newStatsRow(g.Key, g.StatusStats))
+		}
+
+		switch format {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rows)
+		case "csv":
+			return printStatsCSV(rows)
+		}
+		printStatsTable(strings.ToUpper(by), rows)
+		return nil
+	},
+}
+
+func newStatsRow(key string, t session.StatusStats) statsRow {
+	return statsRow{
+		Key:          key,
+		Sessions:     t.Sessions,
+		Running:      int64(t.Running.Seconds()),
+		Waiting:      int64(t.Waiting.Seconds()),
+		Permission:   int64(t.Blocked.Seconds()),
+		AutoForwards: t.AutoForwards,
+		Sends:        t.Sends,
+		Done:         t.Done,
+		AvgToDone:    int64(t.AvgToDone().Seconds()),
+		StalledPct:   t.StalledPercent(),
+		stats:        t,
+	}
+}
+
+// repoLabel names a repo by its directory, prefixed with the host for
+// remote ones.
+func repoLabel(host, dir string) string {
+	if dir == "" {
+		dir = "-"
+	}
+	return session.Label(host, dir)
+}
+
+func printStatsTable(header string, rows []statsRow) {
+	if len(rows) == 0 {
+		fmt.Println("No history.")
+		return
+	}
+	var total session.StatusStats
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tSESSIONS\tRUNNING\tWAITING\tPERMISSION\tSTALLED\tAUTOFWD\tSENT\tDONE\tAVG TO DONE\n", header)
+	for _, r := range rows {
+		printStatsRow(w, r)
+		total.Add(r.stats)
+	}
+	if len(rows) > 1 {
+		printStatsRow(w, newStatsRow("TOTAL", total))
+	}
+	w.Flush()
+}
+
+func printStatsRow(w *tabwriter.Writer, r statsRow) {
+	toDone := "-"
+	if r.Done > 0 {
+		toDone = session.FormatDuration(r.stats.AvgToDone())
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%d%%\t%d\t%d\t%d\t%s\n",
+		r.Key,
+		r.Sessions,
+		session.FormatDuration(r.stats.Running),
+		session.FormatDuration(r.stats.Waiting),
+		session.FormatDuration(r.stats.Blocked),
+		r.StalledPct,
+		r.AutoForwards,
+		r.Sends,
+		r.Done,
+		toDone,
+	)
+}
+
+func printStatsCSV(rows []statsRow) error {
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"key", "sessions", "running_seconds", "waiting_seconds", "permission_seconds",
+		"stalled_percent", "autoforwards", "sends", "done", "avg_seconds_to_done"})
+	for _, r := range rows {
+		_ = w.Write([]string{
+			r.Key,
+			strconv.Itoa(r.Sessions),
+			strconv.FormatInt(r.Running, 10),
+			strconv.FormatInt(r.Waiting, 10),
+			strconv.FormatInt(r.Permission, 10),
+			strconv.Itoa(r.StalledPct),
+			strconv.Itoa(r.AutoForwards),
+			strconv.Itoa(r.Sends),
+			strconv.Itoa(r.Done),
+			strconv.FormatInt(r.AvgToDone, 10),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	statsCmd.Flags().String("since", "7d", "Only count time from this far back (e.g. 7d, 12h; empty for all)")
+	statsCmd.Flags().String("by", "session", "Group by session or repo")
+	statsCmd.Flags().String("format", "table", "Output format: table, json or csv")
+	rootCmd.AddCommand(statsCmd)
+}
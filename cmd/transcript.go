@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript <[host:]name|uuid>",
+	Short: "Print a session's full Claude conversation",
+	Long: `Prints the whole conversation of a running crab, or of a past session
+matched like resume does (crab name, UUID prefix or first message).
+
+Tool calls and results are shown as one-line summaries unless --tools is
+given. --markdown exports the conversation as Markdown with tool details in
+collapsible blocks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools, _ := cmd.Flags().GetBool("tools")
+		markdown, _ := cmd.Flags().GetBool("markdown")
+
+		fsys, title, workDir, uuid, err := resolveTranscript(args[0])
+		if err != nil {
+			return err
+		}
+		entries, err := session.ReadTranscript(fsys, workDir, uuid)
+		if err != nil {
+			return fmt.Errorf("failed to read session %s: %w", uuid, err)
+		}
+
+		if markdown {
+			fmt.Print(session.TranscriptMarkdown(title, entries))
+		} else {
+			fmt.Print(session.TranscriptText(entries, tools))
+		}
+		return nil
+	},
+}
+
+// resolveTranscript finds the Claude session file for a running crab or,
+// failing that, a past session. Returns the filesystem it lives on, a title,
+// and the workdir and UUID that locate it.
+func resolveTranscript(arg string) (tmux.FileSystem, string, string, string, error) {
+	store, err := state.Open()
+	if err != nil {
+		return nil, "", "", "", fmt.Errorf("failed to open state db: %w", err)
+	}
+	defer store.Close()
+
+	host, name := session.ParseLabel(arg)
+	exec := resolveExecutor(host)
+	fullName := exec.SessionPrefix() + name
+
+	if exec.HasSession(fullName) {
+		workDir, uuid, err := session.TranscriptFile(exec, store, fullName)
+		if err != nil {
+			return nil, "", "", "", err
+		}
+		return exec, arg, workDir, uuid, nil
+	}
+
+	past, err := session.LoadResumable(store, buildExecutors(), true)
+	if err != nil {
+		return nil, "", "", "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	matches := matchResumable(past, arg)
+	if len(matches) == 0 {
+		return nil, "", "", "", fmt.Errorf("no session matches %q", arg)
+	}
+	if len(matches) > 1 {
+		printResumable(matches)
+		return nil, "", "", "", fmt.Errorf("%d sessions match %q, be more specific", len(matches), arg)
+	}
+	cs := matches[0]
+	title := crabName(cs)
+	if title == "" {
+		title = cs.UUID
+	}
+	return resolveExecutor(cs.Host), title, cs.ProjectDir, cs.UUID, nil
+}
+
+func init() {
+	transcriptCmd.Flags().Bool("tools", false, "Show full tool inputs and results")
+	transcriptCmd.Flags().BoolP("markdown", "m", false, "Export as Markdown")
+	rootCmd.AddCommand(transcriptCmd)
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// usageRow is the machine-readable form of a usage group for JSON output.
+type usageRow struct {
+	Key        string  `json:"key"`
+	Messages   int     `json:"messages"`
+	Input      int64   `json:"input_tokens"`
+	Output     int64   `json:"output_tokens"`
+	CacheWrite int64   `json:"cache_write_tokens"`
+	CacheRead  int64   `json:"cache_read_tokens"`
+	Cost       float64 `json:"cost_usd"`
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage and estimated cost of Claude sessions",
+	Long: `Sums the token usage recorded in Claude's session files on every host and
+estimates its cost. Prices per million tokens can be overridden under
+"prices" in ~/.config/crabctl/config.yaml, keyed by a model name fragment:
+
+  prices:
+    opus:
+      input: 15
+      output: 75
+      cache_write: 18.75
+      cache_read: 1.5`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		by, _ := cmd.Flags().GetString("by")
+		output, _ := cmd.Flags().GetString("output")
+
+		switch by {
+		case "session", "repo", "host", "day":
+		default:
+			return fmt.Errorf("invalid grouping %q: use session, repo, host or day", by)
+		}
+		switch output {
+		case "table", "json":
+		default:
+			return fmt.Errorf("invalid output format %q: use table or json", output)
+		}
+
+		var since time.Time
+		if sinceFlag != "" {
+			d, err := parseSince(sinceFlag)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-d)
+		}
+
+		names := make(map[string]string)
+		if by == "session" {
+			if store, err := state.Open(); err == nil {
+				if past, err := store.ListResumable(10000); err == nil {
+					for _, ps := range past {
+						names[ps.SessionUUID] = ps.Name
+					}
+				}
+				store.Close()
+			}
+		}
+
+		pricing := session.LoadPricing()
+		groups := make(map[string]*session.UsageTotals)
+		for _, hr := range readAllUsage(buildExecutors(), since) {
+			for _, r := range hr.records {
+				key := usageKey(by, hr.host, r, names)
+				t := groups[key]
+				if t == nil {
+					t = &session.UsageTotals{}
+					groups[key] = t
+				}
+				t.Add(r, pricing)
+			}
+		}
+
+		rows := make([]usageRow, 0, len(groups))
+		for key, t := range groups {
+			rows = append(rows, usageRow{
+				Key:        key,
+				Messages:   t.Messages,
+				Input:      t.Input,
+				Output:     t.Output,
+				CacheWrite: t.CacheWrite,
+				CacheRead:  t.CacheRead,
+				Cost:       t.Cost,
+			})
+		}
+		// Days read best in order, everything else most expensive first
+		sort.Slice(rows, func(i, j int) bool {
+			if by == "day" {
+				return rows[i].Key < rows[j].Key
+			}
+			if rows[i].Cost != rows[j].Cost {
+				return rows[i].Cost > rows[j].Cost
+			}
+			return rows[i].Key < rows[j].Key
+		})
+
+		if output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rows)
+		}
+		printUsageTable(strings.ToUpper(by), rows)
+		return nil
+	},
+}
+
+// hostUsage holds the usage records read from one host.
+type hostUsage struct {
+	host    string
+	records []session.UsageRecord
+}
+
+// readAllUsage reads usage records from every executor in parallel.
+func readAllUsage(executors []tmux.Executor, since time.Time) []hostUsage {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		all []hostUsage
+	)
+	for _, ex := range executors {
+		wg.Add(1)
+		go func(ex tmux.Executor) {
+			defer wg.Done()
+			records := session.ReadUsageSince(ex, since)
+			mu.Lock()
+			all = append(all, hostUsage{host: ex.HostName(), records: records})
+			mu.Unlock()
+		}(ex)
+	}
+	wg.Wait()
+	return all
+}
+
+// usageKey returns the group a usage record belongs to.
+// names maps session UUIDs to crab names.
+func usageKey(by, host string, r session.UsageRecord, names map[string]string) string {
+	switch by {
+	case "host":
+		return session.HostLabel(host)
+	case "day":
+		return r.Time.Local().Format("2006-01-02")
+	case "repo":
+		return repoLabel(host, r.CWD)
+	default:
+		label := r.SessionUUID
+		if name := names[r.SessionUUID]; name != "" {
+			label = name + " (" + shortUUID(r.SessionUUID) + ")"
+		}
+		return session.Label(host, label)
+	}
+}
+
+// parseSince parses a lookback like "7d", "12h" or "90m".
+// Days are accepted in addition to time.ParseDuration units.
+func parseSince(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid --since %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid --since %q: use e.g. 7d, 12h or 90m", s)
+	}
+	return d, nil
+}
+
+func printUsageTable(header string, rows []usageRow) {
+	if len(rows) == 0 {
+		fmt.Println("No usage.")
+		return
+	}
+	var total usageRow
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tMSGS\tINPUT\tOUTPUT\tCACHE WRITE\tCACHE READ\tCOST\n", header)
+	for _, r := range rows {
+		printUsageRow(w, r)
+		total.Messages += r.Messages
+		total.Input += r.Input
+		total.Output += r.Output
+		total.CacheWrite += r.CacheWrite
+		total.CacheRead += r.CacheRead
+		total.Cost += r.Cost
+	}
+	if len(rows) > 1 {
+		total.Key = "TOTAL"
+		printUsageRow(w, total)
+	}
+	w.Flush()
+}
+
+func printUsageRow(w *tabwriter.Writer, r usageRow) {
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+		r.Key,
+		r.Messages,
+		session.FormatTokens(r.Input),
+		session.FormatTokens(r.Output),
+		session.FormatTokens(r.CacheWrite),
+		session.FormatTokens(r.CacheRead),
+		session.FormatCost(r.Cost),
+	)
+}
+
+func init() {
+	usageCmd.Flags().String("since", "7d", "Only count messages from this far back (e.g. 7d, 12h; empty for all)")
+	usageCmd.Flags().String("by", "session", "Group by: session, repo, host or day")
+	usageCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	rootCmd.AddCommand(usageCmd)
+}
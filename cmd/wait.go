@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// Exit codes for crabctl wait.
+const (
+	exitWaitReached  = 0
+	exitWaitTimeout  = 2
+	exitWaitVanished = 3
+)
+
+// minWaitInterval is the shortest polling interval crabctl wait accepts, so
+// a small --interval can't turn it into a busy loop of tmux captures.
+const minWaitInterval = 100 * time.Millisecond
+
+// waitTarget is a session being waited on.
+type waitTarget struct {
+	label    string // as given on the command line
+	fullName string
+	exec     tmux.Executor
+	reached  bool
+	vanished bool
+	status   session.Status
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait <[host:]name>...",
+	Short: "Block until sessions reach a given status",
+	Long: `Polls one or more sessions until they reach one of the requested statuses.
+
+Exit codes:
+  0  status reached
+  2  timed out
+  3  session disappeared`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		forFlag, _ := cmd.Flags().GetStringSlice("for")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		all, _ := cmd.Flags().GetBool("all")
+		anyFlag, _ := cmd.Flags().GetBool("any")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		if all && anyFlag {
+			return fmt.Errorf("--any and --all are mutually exclusive")
+		}
+		if interval < minWaitInterval {
+			return fmt.Errorf("--interval must be at least %s", minWaitInterval)
+		}
+
+		want := make(map[session.Status]bool)
+		for _, f := range forFlag {
+			st, err := session.ParseStatus(f)
+			if err != nil {
+				return err
+			}
+			want[st] = true
+		}
+
+		targets := make([]*waitTarget, 0, len(args))
+		for _, arg := range args {
+			host, name := session.ParseLabel(arg)
+			exec := resolveExecutor(host)
+			fullName := exec.SessionPrefix() + name
+			if !exec.HasSession(fullName) {
+				return &exitError{code: exitWaitVanished, err: fmt.Errorf("session %q not found", arg)}
+			}
+			targets = append(targets, &waitTarget{label: arg, fullName: fullName, exec: exec})
+		}
+
+		code := waitForStatus(targets, want, all, timeout, interval)
+		if !quiet {
+			for _, t := range targets {
+				switch {
+				case t.reached:
+					fmt.Printf("%s: %s\n", t.label, t.status)
+				case t.vanished:
+					fmt.Printf("%s: gone\n", t.label)
+				default:
+					fmt.Printf("%s: %s (timed out)\n", t.label, t.status)
+				}
+			}
+		}
+		if code != exitWaitReached {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &exitError{code: code}
+		}
+		return nil
+	},
+}
+
+// waitForStatus polls targets until the wanted status is reached by any
+// (or, with all set, every) target. Every target is captured on each poll,
+// so with all set they must all be in a wanted status at the same time,
+// not just each at some point. Returns one of the exitWait* codes. A zero
+// timeout waits forever.
+func waitForStatus(targets []*waitTarget, want map[session.Status]bool, all bool, timeout, interval time.Duration) int {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		reached, vanished := 0, 0
+		for _, t := range targets {
+			if !t.vanished {
+				output, err := t.exec.CapturePaneOutput(t.fullName, 25)
+				if err != nil {
+					t.vanished = !t.exec.HasSession(t.fullName)
+					t.reached = false
+				} else {
+					t.status = session.DetectStatus(output)
+					t.reached = want[t.status]
+				}
+			}
+			if t.reached {
+				reached++
+			}
+			if t.vanished {
+				vanished++
+			}
+		}
+
+		if all {
+			if vanished > 0 {
+				return exitWaitVanished
+			}
+			if reached == len(targets) {
+				return exitWaitReached
+			}
+		} else {
+			if reached > 0 {
+				return exitWaitReached
+			}
+			if vanished == len(targets) {
+				return exitWaitVanished
+			}
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return exitWaitTimeout
+		}
+		time.Sleep(interval)
+	}
+}
+
+// exitError carries a specific process exit code out of a command.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func init() {
+	waitCmd.Flags().StringSlice("for", []string{"waiting"}, "Status(es) to wait for: waiting, task-done, permission, confirm, running")
+	waitCmd.Flags().Duration("timeout", 30*time.Minute, "Give up after this long (0 waits forever)")
+	waitCmd.Flags().Duration("interval", 2*time.Second, "Polling interval")
+	waitCmd.Flags().Bool("any", false, "Return as soon as any session reaches the status (default)")
+	waitCmd.Flags().Bool("all", false, "Wait until every session reaches the status")
+	waitCmd.Flags().BoolP("quiet", "q", false, "Don't print final statuses")
+	rootCmd.AddCommand(waitCmd)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/webhook"
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Manage outgoing webhooks",
+	Long: `Webhooks receive session events as JSON POSTs. They are sent by the TUI
+or, while it runs, by crabctl daemon. Targets are set in the config file:
+
+  webhooks:
+    team-chat:
+      url: https://hooks.slack.com/services/...
+      events: [task_done, permission_requested]
+    tracker:
+      url: https://tracker.example.com/crabctl
+      secret: some-shared-secret
+      headers:
+        Authorization: Bearer abc123
+      retries: 5
+      timeout: 5s
+
+Events are session_created, session_killed, status_changed,
+autoforward_sent, task_done and permission_requested; a target without
+events gets all of them. Bodies look like
+
+  {"event": "task_done", "time": "...", "text": "fix-login is done",
+   "session": {"name": "fix-login", "host": "local", "status": "task done", ...},
+   "previous": "running"}
+
+Each delivery has X-Crabctl-Timestamp, its Unix time. With a secret,
+X-Crabctl-Signature is "sha256=" and the hex HMAC-SHA256 of the timestamp,
+a ".", and the body; receivers should also reject old timestamps (say over
+five minutes) so a captured delivery can't be replayed. Failed deliveries (network errors, 429 and 5xx) are retried with
+exponential backoff.`,
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:   "test [name...]",
+	Short: "Send a test event to webhooks",
+	Long: `Sends a "test" event to the named webhooks, or to all of them, whatever
+events they subscribe to, and reports how each delivery went.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sender, err := webhook.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load webhooks: %w", err)
+		}
+		names := args
+		if len(names) == 0 {
+			names = sender.Targets()
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no webhooks configured in %s", filepath.Join(config.Dir(), "config.yaml"))
+		}
+
+		host, _ := os.Hostname()
+		ev := webhook.Event{
+			Event:   webhook.Test,
+			Time:    time.Now().UTC(),
+			Text:    "crabctl webhook test from " + host,
+			Session: session.Entry{Name: "test", Host: "local", Status: session.Waiting.String()},
+		}
+		failed := 0
+		for _, name := range names {
+			if err := sender.SendTo(context.Background(), name, ev); err != nil {
+				fmt.Printf("%s: %v\n", name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s: ok\n", name)
+		}
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d of %d webhooks failed", failed, len(names))
+		}
+		return nil
+	},
+}
+
+func init() {
+	webhookCmd.AddCommand(webhookTestCmd)
+	rootCmd.AddCommand(webhookCmd)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/simon/crabctl/internal/session"
+)
+
+// keepAliveInterval is how long /events may stay silent before it sends a
+// comment, so proxies don't drop the connection.
+const keepAliveInterval = 15 * time.Second
+
+// statusEvent is the data of a "status" event: a session that appeared or
+// changed status. Previous is empty for new sessions.
+type statusEvent struct {
+	session.Entry
+	Previous string `json:"previous,omitempty"`
+}
+
+// handleEvents streams status changes as server-sent events. It starts with
+// a "status" event for every current session, then sends one whenever a
+// session appears or its status changes, and a "gone" event when it
+// disappears.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.EventInterval)
+	defer ticker.Stop()
+	last := make(map[string]session.Entry) // host:name -> last sent
+	lastWrite := time.Now()
+	for {
+		hooks, _ := s.store.LoadHookEvents()
+		sessions, _ := session.ListAll(s.executors, hooks)
+		seen := make(map[string]bool, len(sessions))
+		sent := false
+		for _, sess := range sessions {
+			e := session.NewEntry(sess)
+			key := e.Host + ":" + e.Name
+			seen[key] = true
+			prev, known := last[key]
+			if known && prev.Status == e.Status {
+				continue
+			}
+			last[key] = e
+			writeEvent(w, "status", statusEvent{Entry: e, Previous: prev.Status})
+			sent = true
+		}
+		for key, e := range last {
+			if !seen[key] {
+				delete(last, key)
+				writeEvent(w, "gone", map[string]string{"name": e.Name, "host": e.Host})
+				sent = true
+			}
+		}
+		if !sent && time.Since(lastWrite) >= keepAliveInterval {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			sent = true
+		}
+		if sent {
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
@@ -0,0 +1,404 @@
+// Package api serves crabctl's HTTP/JSON API for dashboards and editor
+// plugins, backed by the same executors and state store as the CLI.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// Server handles API requests.
+type Server struct {
+	// EventInterval is how often /events polls for status changes.
+	EventInterval time.Duration
+
+	executors []tmux.Executor
+	store     *state.Store
+	token     string // required bearer token, "" for none
+}
+
+// New returns a server for the given executors. Requests must carry token
+// as a bearer token unless it is empty.
+func New(executors []tmux.Executor, store *state.Store, token string) *Server {
+	return &Server{
+		EventInterval: 2 * time.Second,
+		executors:     executors,
+		store:         store,
+		token:         token,
+	}
+}
+
+// Handler returns the API's routes:
+//
+//	GET    /sessions                      list sessions
+//	POST   /sessions                      create a session
+//	GET    /sessions/{id}?lines=N         one session with a pane preview
+//	DELETE /sessions/{id}                 kill a session
+//	POST   /sessions/{id}/send            send or queue text
+//	PUT    /sessions/{id}/autoforward     enable or disable autoforward
+//	GET    /resumable?all=1               past sessions that can be resumed
+//	POST   /resumable/{uuid}/resume       resume a past session
+//	GET    /events                        server-sent status changes
+//
+// A session {id} is its name, prefixed with "host:" for remote hosts.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions", s.handleList)
+	mux.HandleFunc("POST /sessions", s.handleNew)
+	mux.HandleFunc("GET /sessions/{id}", s.handleGet)
+	mux.HandleFunc("DELETE /sessions/{id}", s.handleKill)
+	mux.HandleFunc("POST /sessions/{id}/send", s.handleSend)
+	mux.HandleFunc("PUT /sessions/{id}/autoforward", s.handleAutoForward)
+	mux.HandleFunc("GET /resumable", s.handleResumable)
+	mux.HandleFunc("POST /resumable/{uuid}/resume", s.handleResume)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	if s.token == "" {
+		return mux
+	}
+	return s.requireToken(mux)
+}
+
+// requireToken rejects requests without the bearer token. EventSource
+// clients, which can't set headers, may pass it as ?token= instead.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			got = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionDetail is a session with what GET /sessions/{id} adds.
+type sessionDetail struct {
+	session.Entry
+	Prompt  string `json:"prompt,omitempty"` // pending permission prompt
+	Queued  int    `json:"queued"`           // messages waiting for the session to be idle
+	Preview string `json:"preview"`          // bottom of the pane, cleaned up
+}
+
+// resumable is a past session in GET /resumable.
+type resumable struct {
+	Name         string    `json:"name"`
+	Host         string    `json:"host"`
+	UUID         string    `json:"uuid"`
+	Dir          string    `json:"dir"`
+	FirstMessage string    `json:"first_message"`
+	State        string    `json:"state"`
+	LastActive   time.Time `json:"last_active"`
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	hooks, _ := s.store.LoadHookEvents()
+	sessions, _ := session.ListAll(s.executors, hooks)
+	entries := make([]session.Entry, 0, len(sessions))
+	for _, sess := range sessions {
+		entries = append(entries, session.NewEntry(sess))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	ex, fullName, err := session.Resolve(s.executors, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	hooks, _ := s.store.LoadHookEvents()
+	sessions, err := session.ListExecutor(ex, hooks)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	lines := 50
+	if n, err := strconv.Atoi(r.URL.Query().Get("lines")); err == nil && n > 0 {
+		lines = min(n, 2000)
+	}
+	for _, sess := range sessions {
+		if sess.FullName != fullName {
+			continue
+		}
+		d := sessionDetail{Entry: session.NewEntry(sess)}
+		if sess.Prompt != nil {
+			d.Prompt = sess.Prompt.Summary()
+		}
+		if depths, err := s.store.QueueDepths(); err == nil {
+			d.Queued = depths[state.QueueKey(sess.Host, fullName)]
+		}
+		if output, err := ex.CapturePaneOutput(fullName, lines); err == nil {
+			d.Preview = session.CleanPaneOutput(output)
+		}
+		writeJSON(w, http.StatusOK, d)
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("session %q %w", r.PathValue("id"), session.ErrNotFound))
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text  string `json:"text"`
+		Queue bool   `json:"queue"` // send once the session is idle
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Text == "" {
+		writeError(w, http.StatusBadRequest, errors.New("text is required"))
+		return
+	}
+	ex, fullName, err := session.Resolve(s.executors, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if req.Queue {
+		n, err := s.store.EnqueueMessage(fullName, ex.HostName(), req.Text)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]int{"queued": n})
+		return
+	}
+	if err := session.SendMessage(ex, fullName, req.Text); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	_ = s.store.RecordEvent(state.Event{Name: fullName, Host: ex.HostName(), Kind: state.EventSend, Detail: req.Text})
+	writeJSON(w, http.StatusOK, map[string]bool{"sent": true})
+}
+
+func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name           string   `json:"name"`
+		Host           string   `json:"host"`
+		Dir            string   `json:"dir"`
+		Template       string   `json:"template"`
+		Message        string   `json:"message"` // sent once Claude is ready
+		PermissionMode string   `json:"permission_mode"`
+		Model          string   `json:"model"`
+		ClaudeArgs     []string `json:"claude_args"`
+		Worktree       bool     `json:"worktree"`
+		WorktreeBranch string   `json:"worktree_branch"` // default the name
+		AutoForward    bool     `json:"autoforward"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	opts := session.CreateOptions{
+		Name:     req.Name,
+		Host:     req.Host,
+		Dir:      req.Dir,
+		Template: req.Template,
+		Message:  req.Message,
+		Claude: config.ClaudeConfig{
+			PermissionMode: req.PermissionMode,
+			Model:          req.Model,
+			Args:           req.ClaudeArgs,
+		},
+		Worktree:       req.Worktree,
+		WorktreeBranch: req.WorktreeBranch,
+		AutoForward:    req.AutoForward,
+	}
+	created, err := session.Create(s.executors, s.store, opts)
+	if err != nil {
+		writeError(w, createStatus(err), err)
+		return
+	}
+	ex, fullName := created.Executor, created.FullName
+
+	resp := struct {
+		Name        string `json:"name"`
+		Host        string `json:"host"`
+		Dir         string `json:"dir"`
+		Worktree    string `json:"worktree,omitempty"`
+		MessageSent bool   `json:"message_sent"`
+		Warning     string `json:"warning,omitempty"`
+	}{Name: req.Name, Host: session.HostLabel(ex.HostName()), Dir: created.Dir}
+	if created.Worktree != nil {
+		resp.Worktree = created.Worktree.Path
+	}
+	if created.Message != "" {
+		if err := session.WaitForPrompt(ex, fullName); err != nil {
+			resp.Warning = fmt.Sprintf("%v (session created but message not sent)", err)
+		} else if err := session.SendMessage(ex, fullName, created.Message); err != nil {
+			resp.Warning = fmt.Sprintf("failed to send message: %v", err)
+		} else {
+			resp.MessageSent = true
+		}
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// createStatus maps a session.Create error to an HTTP status.
+func createStatus(err error) int {
+	switch {
+	case errors.Is(err, session.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, session.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, session.ErrExists):
+		return http.StatusConflict
+	}
+	return http.StatusBadGateway
+}
+
+func (s *Server) handleKill(w http.ResponseWriter, r *http.Request) {
+	ex, fullName, err := session.Resolve(s.executors, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := session.Kill(ex, s.store, fullName); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to kill session: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"killed": true})
+}
+
+func (s *Server) handleAutoForward(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, http.StatusBadRequest, errors.New("enabled is required"))
+		return
+	}
+	ex, fullName, err := session.Resolve(s.executors, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := s.store.SetAutoForward(fullName, ex.HostName(), *req.Enabled); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"autoforward": *req.Enabled})
+}
+
+func (s *Server) handleResumable(w http.ResponseWriter, r *http.Request) {
+	past, err := session.LoadResumable(s.store, s.executors, r.URL.Query().Get("all") != "")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]resumable, 0, len(past))
+	for _, cs := range past {
+		out = append(out, resumable{
+			Name:         cs.Name,
+			Host:         session.HostLabel(cs.Host),
+			UUID:         cs.UUID,
+			Dir:          cs.ProjectDir,
+			FirstMessage: cs.FirstMessage,
+			State:        cs.State(),
+			LastActive:   cs.ModTime,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string  `json:"name"` // default: the original crab name
+		Host *string `json:"host"` // default: where the session file lives
+	}
+	if r.ContentLength != 0 && !readJSON(w, r, &req) {
+		return
+	}
+	past, err := session.LoadResumable(s.store, s.executors, true)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var matches []session.ClaudeSession
+	for _, cs := range past {
+		if strings.HasPrefix(cs.UUID, r.PathValue("uuid")) {
+			matches = append(matches, cs)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no resumable session %q", r.PathValue("uuid")))
+		return
+	case len(matches) > 1:
+		writeError(w, http.StatusConflict, fmt.Errorf("%d sessions match %q", len(matches), r.PathValue("uuid")))
+		return
+	}
+	cs := matches[0]
+
+	host := cs.Host
+	if req.Host != nil {
+		host = *req.Host
+	}
+	ex, err := session.FindExecutor(s.executors, host)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	name := req.Name
+	if name == "" {
+		name = session.SuggestName(cs, ex.SessionPrefix())
+	}
+	if !session.ValidName(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid name %q: use only alphanumeric, hyphens, underscores", name))
+		return
+	}
+	fullName := ex.SessionPrefix() + name
+	if ex.HasSession(fullName) {
+		writeError(w, http.StatusConflict, fmt.Errorf("session %q already exists", name))
+		return
+	}
+	claudeBin, claudeArgs, err := session.ResumeLaunchArgs(cs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := ex.NewSession(name, cs.ProjectDir, claudeBin, claudeArgs); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to create session: %w", err))
+		return
+	}
+	_ = s.store.RecordEvent(state.Event{Name: fullName, Host: ex.HostName(), Kind: state.EventResume, Detail: cs.UUID, WorkDir: cs.ProjectDir})
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"name": name,
+		"host": session.HostLabel(ex.HostName()),
+		"uuid": cs.UUID,
+		"dir":  cs.ProjectDir,
+	})
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
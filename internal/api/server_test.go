@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestHandler(t *testing.T) {
+	h := New([]tmux.Executor{}, nil, "sekret").Handler()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		body   string
+		want   int
+	}{
+		{"no token", "GET", "/sessions", "", "", http.StatusUnauthorized},
+		{"wrong token", "GET", "/sessions", "Bearer nope", "", http.StatusUnauthorized},
+		{"list", "GET", "/sessions", "Bearer sekret", "", http.StatusOK},
+		{"token in query", "GET", "/sessions?token=sekret", "", "", http.StatusOK},
+		{"unknown host", "GET", "/sessions/bay9:crab", "Bearer sekret", "", http.StatusNotFound},
+		{"invalid name", "POST", "/sessions", "Bearer sekret", `{"name": "a b"}`, http.StatusBadRequest},
+		{"bad json", "POST", "/sessions/crab/send", "Bearer sekret", `{`, http.StatusBadRequest},
+		{"wrong method", "PATCH", "/sessions", "Bearer sekret", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
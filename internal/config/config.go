@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,10 +19,208 @@ type HostConfig struct {
 	User   string `yaml:"user"`
 	SSHKey string `yaml:"ssh_key"`
 	Prefix string `yaml:"prefix"`
+	// Claude overrides the top-level claude defaults on this host.
+	Claude ClaudeConfig `yaml:"claude"`
+}
+
+// ClaudeConfig is how new sessions start Claude. Empty fields keep the
+// built-in defaults: "claude" in bypass permission mode.
+type ClaudeConfig struct {
+	Bin            string   `yaml:"bin"`             // claude binary or wrapper script
+	PermissionMode string   `yaml:"permission_mode"` // default, plan, acceptEdits or bypass
+	Model          string   `yaml:"model"`           // passed as --model
+	Args           []string `yaml:"args"`            // extra arguments, appended after the above
+}
+
+// Merge returns c with the fields set in o taking precedence; o's Args
+// are appended to c's.
+func (c ClaudeConfig) Merge(o ClaudeConfig) ClaudeConfig {
+	if o.Bin != "" {
+		c.Bin = o.Bin
+	}
+	if o.PermissionMode != "" {
+		c.PermissionMode = o.PermissionMode
+	}
+	if o.Model != "" {
+		c.Model = o.Model
+	}
+	c.Args = append(append([]string(nil), c.Args...), o.Args...)
+	return c
+}
+
+// ModelPrice is the USD price per million tokens for a model family.
+type ModelPrice struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheWrite float64 `yaml:"cache_write"`
+	CacheRead  float64 `yaml:"cache_read"`
 }
 
 type Config struct {
 	Hosts map[string]HostConfig `yaml:"hosts"`
+	// Prices overrides the built-in price table. Keys match any model
+	// name containing them, e.g. "opus" or "sonnet-4-5".
+	Prices map[string]ModelPrice `yaml:"prices"`
+	// Claude sets how new sessions start Claude on every host.
+	Claude ClaudeConfig `yaml:"claude"`
+	// Templates are named recipes for `crabctl new --template`.
+	Templates map[string]Template `yaml:"templates"`
+	// WorktreeRoot is where `crabctl new --worktree` creates worktrees,
+	// as <root>/<repo>/<name>. "~/" is the home dir on the session's host.
+	WorktreeRoot string `yaml:"worktree_root"`
+	// AutoForward overrides the built-in autoforward policy for sessions
+	// that don't set their own with `crabctl set`.
+	AutoForward AutoForwardConfig `yaml:"autoforward"`
+	// API configures `crabctl serve`.
+	API APIConfig `yaml:"api"`
+	// Notify sets how you're told that a session needs attention.
+	Notify NotifyConfig `yaml:"notify"`
+	// Webhooks are named targets that receive session events as JSON POSTs.
+	Webhooks map[string]WebhookConfig `yaml:"webhooks"`
+	// History sets how long the session event history is kept.
+	History HistoryConfig `yaml:"history"`
+}
+
+// DefaultHistoryRetentionDays is how long events are kept when
+// history.retention_days isn't set.
+const DefaultHistoryRetentionDays = 30
+
+// HistoryConfig configures the session event history shown by
+// `crabctl history`.
+type HistoryConfig struct {
+	RetentionDays int `yaml:"retention_days"` // default 30; negative keeps events forever
+}
+
+// Retention returns how long events are kept, or 0 to keep them forever.
+func (c HistoryConfig) Retention() time.Duration {
+	days := c.RetentionDays
+	if days == 0 {
+		days = DefaultHistoryRetentionDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// WebhookConfig is a target for session events. Events lists the event
+// types to send (session_created, session_killed, status_changed,
+// autoforward_sent, task_done, permission_requested); empty means all.
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Events  []string          `yaml:"events"`
+	Secret  string            `yaml:"secret"`  // signs bodies with HMAC-SHA256 in X-Crabctl-Signature
+	Headers map[string]string `yaml:"headers"` // extra request headers, e.g. Authorization
+	Retries *int              `yaml:"retries"` // retries after a failed delivery, default 3
+	Timeout time.Duration     `yaml:"timeout"` // per attempt, default 10s
+}
+
+// NotifyConfig sets the notifiers fired when a session changes to one of
+// the On statuses. Commands are run without a shell; each argument of
+// Command is a Go template that can use {{.Name}}, {{.Host}}, {{.Status}},
+// {{.Previous}} and {{.Message}}, e.g.
+//
+//	command: [notify-send, crabctl, "{{.Message}}"]
+type NotifyConfig struct {
+	On          []string `yaml:"on"`           // statuses that notify, default permission, confirm and task-done
+	Command     []string `yaml:"command"`      // run with templated arguments
+	JSONCommand []string `yaml:"json_command"` // run with the event as JSON on stdin
+	Bell        bool     `yaml:"bell"`         // ring the terminal bell
+	Tmux        bool     `yaml:"tmux"`         // tmux display-message on every attached client
+}
+
+// APIConfig configures the HTTP API served by `crabctl serve`.
+type APIConfig struct {
+	// Token must be sent as "Authorization: Bearer <token>". TCP listeners
+	// refuse to start without one; unix sockets rely on file permissions.
+	Token string `yaml:"token"`
+}
+
+// AutoForwardConfig is how autoforward nudges a waiting session. Zero
+// fields keep the defaults.
+type AutoForwardConfig struct {
+	Message        string        `yaml:"message"`           // sent to the waiting session
+	Delay          time.Duration `yaml:"delay"`             // how long it must wait first, e.g. 30s
+	Max            int           `yaml:"max"`               // forwards in a row before giving up
+	StopOnTaskDone *bool         `yaml:"stop_on_task_done"` // leave sessions that said TASK DONE alone
+}
+
+// Merge returns c with the fields set in o taking precedence.
+func (c AutoForwardConfig) Merge(o AutoForwardConfig) AutoForwardConfig {
+	if o.Message != "" {
+		c.Message = o.Message
+	}
+	if o.Delay != 0 {
+		c.Delay = o.Delay
+	}
+	if o.Max != 0 {
+		c.Max = o.Max
+	}
+	if o.StopOnTaskDone != nil {
+		c.StopOnTaskDone = o.StopOnTaskDone
+	}
+	return c
+}
+
+// StopsOnTaskDone reports whether sessions that said TASK DONE are left
+// alone, which is the default.
+func (c AutoForwardConfig) StopsOnTaskDone() bool {
+	return c.StopOnTaskDone == nil || *c.StopOnTaskDone
+}
+
+// IsZero reports whether no field is set.
+func (c AutoForwardConfig) IsZero() bool {
+	return c.Message == "" && c.Delay == 0 && c.Max == 0 && c.StopOnTaskDone == nil
+}
+
+// DefaultWorktreeRoot is used when worktree_root isn't configured.
+const DefaultWorktreeRoot = "~/.local/share/crabctl/worktrees"
+
+// Template is a named recipe for new sessions. Message is a Go template
+// that can use {{.Name}} (the session name) and {{.Arg}} (the text given
+// after the name).
+type Template struct {
+	Host        string       `yaml:"host"`
+	Dir         string       `yaml:"dir"`
+	Claude      ClaudeConfig `yaml:"claude"`
+	Message     string       `yaml:"message"`
+	AutoForward bool         `yaml:"autoforward"`
+}
+
+// Template returns the named template.
+func (c *Config) Template(name string) (Template, error) {
+	t, ok := c.Templates[name]
+	if !ok {
+		names := make([]string, 0, len(c.Templates))
+		for n := range c.Templates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return t, fmt.Errorf("unknown template %q: none defined in %s", name, filepath.Join(Dir(), "config.yaml"))
+		}
+		return t, fmt.Errorf("unknown template %q (have %s)", name, strings.Join(names, ", "))
+	}
+	return t, nil
+}
+
+// ClaudeFor returns the claude defaults for a host ("" for local).
+func (c *Config) ClaudeFor(host string) ClaudeConfig {
+	cc := ClaudeConfig{}.Merge(c.Claude)
+	if h, ok := c.Hosts[host]; ok && host != "" {
+		cc = cc.Merge(h.Claude)
+	}
+	return cc
+}
+
+// Dir returns the crabctl config directory, $XDG_CONFIG_HOME/crabctl.
+func Dir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "crabctl")
 }
 
 // Load reads the config from $XDG_CONFIG_HOME/crabctl/config.yaml.
@@ -30,14 +231,9 @@ func Load() (*Config, error) {
 		return &Config{}, nil
 	}
 
-	configHome := os.Getenv("XDG_CONFIG_HOME")
-	if configHome == "" {
-		configHome = filepath.Join(home, ".config")
-	}
-
 	var cfg Config
 
-	path := filepath.Join(configHome, "crabctl", "config.yaml")
+	path := filepath.Join(Dir(), "config.yaml")
 	data, err := os.ReadFile(path)
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyConfig lists the rules for answering permission prompts
+// automatically. Rules look like Claude Code permission rules: a tool name,
+// optionally followed by an argument pattern in parentheses where * matches
+// anything, e.g. "Bash(go test*)", "Edit(*.md)" or "Read".
+type PolicyConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// PolicyPath returns the path of the auto-approval policy file.
+func PolicyPath() string {
+	return filepath.Join(Dir(), "policy.yaml")
+}
+
+// LoadPolicy reads the policy from $XDG_CONFIG_HOME/crabctl/policy.yaml.
+// Returns nil if the file doesn't exist.
+func LoadPolicy() (*PolicyConfig, error) {
+	data, err := os.ReadFile(PolicyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pc PolicyConfig
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return nil, err
+	}
+	return &pc, nil
+}
@@ -0,0 +1,261 @@
+// Package daemon does the TUI's background work without a terminal:
+// polling sessions, persisting their Claude session UUIDs and event history,
+// autoforward, queued message delivery, status notifications and webhooks.
+package daemon
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+	"github.com/simon/crabctl/internal/webhook"
+)
+
+// Daemon polls sessions on a set of executors and acts on them.
+type Daemon struct {
+	Interval       time.Duration // how often local sessions are polled
+	RemoteInterval time.Duration // how often remote hosts are polled
+
+	executors   []tmux.Executor
+	store       *state.Store
+	pricing     session.Pricing
+	log         *log.Logger
+	autoForward *session.AutoForwarder
+	queue       *session.QueueDispatcher
+	notifier    *session.Notifier
+	webhooks    *webhook.Queue
+	transitions []session.Transition         // status changes found by the last refresh
+	events      []webhook.Event              // webhook events not sent yet
+	sessions    map[string][]session.Session // host -> last listing
+	polledAt    map[string]time.Time         // host -> last listing time
+	hostErr     map[string]string            // host -> last listing error, logged once
+	prunedAt    time.Time                    // last time old history was deleted
+}
+
+// pruneInterval is how often history older than the retention is deleted.
+const pruneInterval = time.Hour
+
+// New returns a daemon for the given executors. store is required: it holds
+// the autoforward settings, the message queue and the resolved UUIDs.
+func New(executors []tmux.Executor, store *state.Store, logger *log.Logger) *Daemon {
+	notifier, err := session.LoadNotifier()
+	if err != nil {
+		logger.Printf("notifications disabled: %v", err)
+	}
+	webhooks, err := webhook.Load()
+	if err != nil {
+		logger.Printf("webhooks disabled: %v", err)
+	}
+	d := &Daemon{
+		Interval:       1500 * time.Millisecond,
+		RemoteInterval: 5 * time.Second,
+		executors:      executors,
+		store:          store,
+		pricing:        session.LoadPricing(),
+		log:            logger,
+		autoForward:    session.NewAutoForwarder(session.LoadAutoForwardDefaults()),
+		queue:          session.NewQueueDispatcher(),
+		notifier:       notifier,
+		sessions:       make(map[string][]session.Session),
+		polledAt:       make(map[string]time.Time),
+		hostErr:        make(map[string]string),
+	}
+	d.webhooks = webhook.NewQueue(webhooks, func(ev webhook.Event, err error) {
+		d.log.Printf("%s: %v", ev.Event, err)
+	})
+	return d
+}
+
+// Run polls until ctx is done.
+func (d *Daemon) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.Poll(time.Now())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll refreshes the hosts that are due, notifies status changes and sends
+// webhooks, then delivers queued messages and autoforwards.
+func (d *Daemon) Poll(now time.Time) {
+	if now.Sub(d.prunedAt) >= pruneInterval {
+		d.prunedAt = now
+		if n, err := session.PruneHistory(d.store, now); err != nil {
+			d.log.Printf("history: %v", err)
+		} else if n > 0 {
+			d.log.Printf("history: deleted %d old events", n)
+		}
+	}
+	d.refresh(now)
+	d.notify()
+	d.sendWebhooks()
+
+	var all []session.Session
+	for _, sessions := range d.sessions {
+		all = append(all, sessions...)
+	}
+
+	depths, err := d.store.QueueDepths()
+	if err != nil {
+		d.log.Printf("queue: %v", err)
+	}
+	for _, s := range d.queue.Due(all, depths, now) {
+		msg, sent, err := session.DeliverQueued(d.executor(s.Host), d.store, s.FullName, s.Host)
+		switch {
+		case err != nil:
+			d.log.Printf("%s: queued message not sent: %v", s.Label(), err)
+		case sent:
+			d.log.Printf("%s: sent queued message %q", s.Label(), msg.Text)
+		default:
+			d.queue.Retry(state.QueueKey(s.Host, s.FullName))
+		}
+	}
+
+	// Queued messages take precedence over "continue"
+	queued := func(s session.Session) bool {
+		return depths[state.QueueKey(s.Host, s.FullName)] > 0
+	}
+	d.autoForward.Sync(d.store)
+	for _, s := range d.autoForward.Due(all, now, queued) {
+		policy := d.autoForward.Policy(s.FullName, s.Host)
+		sent, err := session.Forward(d.executor(s.Host), s.FullName, policy)
+		if err != nil {
+			d.log.Printf("%s: autoforward failed: %v", s.Label(), err)
+			continue
+		}
+		if sent {
+			d.autoForward.Sent(s.FullName, s.Host)
+			_ = d.store.RecordEvent(state.Event{Name: s.FullName, Host: s.Host, Kind: state.EventAutoForward, Detail: policy.Message, WorkDir: s.WorkDir, RepoDir: s.RepoDir, Time: now})
+			d.events = append(d.events, webhook.AutoForwarded(s, now))
+			left, limit := d.autoForward.Remaining(s.FullName, s.Host)
+			d.log.Printf("%s: autoforwarded (%d of %d left)", s.Label(), left, limit)
+		}
+	}
+}
+
+// refresh lists the sessions of every host due a poll, in parallel, and
+// merges them with what was known so UUIDs are resolved and saved once.
+func (d *Daemon) refresh(now time.Time) {
+	type result struct {
+		host        string
+		sessions    []session.Session
+		transitions []session.Transition
+		appeared    []session.Session
+		gone        []session.Session
+		listed      bool // listed successfully before, so appeared and gone are known
+		err         error
+	}
+	var (
+		wg      sync.WaitGroup
+		results = make(chan result, len(d.executors))
+	)
+	hooks, _ := d.store.LoadHookEvents()
+	for _, ex := range d.executors {
+		host := ex.HostName()
+		interval := d.Interval
+		if host != "" {
+			interval = d.RemoteInterval
+		}
+		if now.Sub(d.polledAt[host]) < interval {
+			continue
+		}
+		d.polledAt[host] = now
+		// Only a successful earlier listing tells what appeared or went
+		known, listed := d.sessions[host]
+		wg.Add(1)
+		go func(ex tmux.Executor) {
+			defer wg.Done()
+			sessions, err := session.ListExecutor(ex, hooks)
+			r := result{host: host, sessions: sessions, listed: listed, err: err}
+			if err == nil {
+				session.MergeState(ex, d.store, d.pricing, known, sessions)
+				r.transitions = session.Transitions(known, sessions)
+				if listed {
+					r.appeared, r.gone = session.Diff(known, sessions)
+				}
+			}
+			results <- r
+		}(ex)
+	}
+	wg.Wait()
+	close(results)
+
+	for r := range results {
+		if r.err != nil {
+			if msg := r.err.Error(); d.hostErr[r.host] != msg {
+				d.log.Printf("%s: %v", session.HostLabel(r.host), r.err)
+				d.hostErr[r.host] = msg
+			}
+			// Don't act on a stale listing
+			delete(d.sessions, r.host)
+			continue
+		}
+		delete(d.hostErr, r.host)
+		d.sessions[r.host] = r.sessions
+		d.transitions = append(d.transitions, r.transitions...)
+		if r.listed {
+			session.RecordListing(d.store, r.appeared, r.gone, r.transitions, now)
+		} else if err := session.ReconcileHistory(d.store, r.host, r.sessions, now); err != nil {
+			d.log.Printf("history: %v", err)
+		}
+		for _, s := range r.appeared {
+			d.events = append(d.events, webhook.Created(s, now))
+		}
+		for _, s := range r.gone {
+			d.events = append(d.events, webhook.Killed(s, now))
+		}
+		for _, t := range r.transitions {
+			d.events = append(d.events, webhook.Transition(t, now)...)
+		}
+	}
+}
+
+// notify fires the configured notifiers for the status changes of the last
+// refresh, except for muted sessions. Notifiers run in the background so a
+// slow command doesn't hold up polling.
+func (d *Daemon) notify() {
+	transitions := d.transitions
+	d.transitions = nil
+	if len(transitions) == 0 || !d.notifier.Enabled() {
+		return
+	}
+	muted, err := d.store.LoadMuted()
+	if err != nil {
+		d.log.Printf("notify: %v", err)
+	}
+	for _, t := range transitions {
+		if muted[state.QueueKey(t.Session.Host, t.Session.FullName)] || !d.notifier.Wants(t) {
+			continue
+		}
+		go func(t session.Transition) {
+			if err := d.notifier.Notify(t); err != nil {
+				d.log.Printf("%s: notify: %v", t.Session.Label(), err)
+			}
+		}(t)
+	}
+}
+
+// sendWebhooks queues the pending events. They are sent in order in the
+// background, so a slow or retrying target doesn't hold up polling.
+func (d *Daemon) sendWebhooks() {
+	d.webhooks.Add(d.events...)
+	d.events = nil
+}
+
+func (d *Daemon) executor(host string) tmux.Executor {
+	for _, ex := range d.executors {
+		if ex.HostName() == host {
+			return ex
+		}
+	}
+	return &tmux.LocalExecutor{}
+}
@@ -0,0 +1,181 @@
+// Package mcp serves crabctl as a Model Context Protocol server over stdio,
+// so a coordinating Claude can manage sessions through tools instead of
+// parsing crabctl's human-oriented output.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Server handles MCP requests for a set of executors.
+type Server struct {
+	executors []tmux.Executor
+	store     *state.Store
+	version   string
+}
+
+// New returns a server for the given executors. store may be nil, in which
+// case queueing and resume information are unavailable.
+func New(executors []tmux.Executor, store *state.Store, version string) *Server {
+	return &Server{executors: executors, store: store, version: version}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes the
+// responses to w until r is exhausted or ctx is done. Requests are handled
+// concurrently, so a long wait_for_status doesn't block other tools.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		enc = json.NewEncoder(w)
+	)
+	write := func(resp response) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(resp)
+	}
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			continue
+		}
+		if req.ID == nil {
+			// Notifications (initialized, cancelled) need no answer
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rerr := s.handle(ctx, req)
+			resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+			if rerr == nil && result == nil {
+				resp.Result = struct{}{}
+			}
+			write(resp)
+		}()
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handle(ctx context.Context, req request) (any, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{codeInvalidRequest, `jsonrpc must be "2.0"`}
+	}
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "crabctl", "version": s.version},
+			"instructions": "Manage Claude Code sessions (crabs) running in tmux. " +
+				"A session is named by its crab name, prefixed with \"host:\" for remote hosts.",
+		}, nil
+	case "ping":
+		return nil, nil
+	case "tools/list":
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		t, ok := toolByName(params.Name)
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		args := params.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		out, err := t.call(s, ctx, args)
+		if err != nil {
+			return toolError(err), nil
+		}
+		return toolResult(out), nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+}
+
+// toolResult wraps a tool's output as both structured content and, for
+// clients that only read text, its JSON encoding.
+func toolResult(out any) map[string]any {
+	b, _ := json.Marshal(out)
+	return map[string]any{
+		"content":           []map[string]string{{"type": "text", "text": string(b)}},
+		"structuredContent": out,
+	}
+}
+
+// toolError reports a failed tool call to the model rather than as a
+// protocol error, so it can correct itself.
+func toolError(err error) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": err.Error()}},
+		"isError": true,
+	}
+}
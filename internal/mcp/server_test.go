@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestServe(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"kill_session","arguments":{"session":"bay9:crab"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"new_session","arguments":{"name":"a b"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`,
+		`{not json`,
+	}, "\n")
+	var out strings.Builder
+	s := New([]tmux.Executor{}, nil, "test")
+	if err := s.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type resp struct {
+		ID     json.RawMessage `json:"id"`
+		Result struct {
+			ProtocolVersion string            `json:"protocolVersion"`
+			Tools           []json.RawMessage `json:"tools"`
+			IsError         bool              `json:"isError"`
+		} `json:"result"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	got := make(map[string]resp)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r resp
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("bad response %q: %v", line, err)
+		}
+		got[string(r.ID)] = r
+	}
+	if len(got) != 8 {
+		t.Errorf("got %d responses, want 8 (notifications get none):\n%s", len(got), out.String())
+	}
+
+	if v := got["1"].Result.ProtocolVersion; v != "2025-03-26" {
+		t.Errorf("protocolVersion = %q, want the client's", v)
+	}
+	if n := len(got["2"].Result.Tools); n != len(tools) {
+		t.Errorf("tools/list returned %d tools, want %d", n, len(tools))
+	}
+	for _, id := range []string{"3", "6"} {
+		if r := got[id]; r.Error != nil || !r.Result.IsError {
+			t.Errorf("id %s: want a tool result with isError, got %+v", id, r)
+		}
+	}
+	wantCodes := map[string]int{"4": codeInvalidParams, "5": codeMethodNotFound, "null": codeParseError}
+	for id, code := range wantCodes {
+		if r := got[id]; r.Error == nil || r.Error.Code != code {
+			t.Errorf("id %s: want error %d, got %+v", id, code, r.Error)
+		}
+	}
+	if r := got["7"]; r.Error != nil {
+		t.Errorf("ping failed: %+v", r.Error)
+	}
+}
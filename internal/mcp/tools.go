@@ -0,0 +1,505 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+const (
+	defaultWaitTimeout = 5 * time.Minute
+	maxWaitTimeout     = time.Hour
+	waitPollInterval   = 2 * time.Second
+	defaultTranscript  = 50 // entries returned by read_transcript
+)
+
+// tool is an MCP tool: its advertised definition and the method that
+// runs it with the call's arguments.
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	call func(s *Server, ctx context.Context, args json.RawMessage) (any, error)
+}
+
+var sessionArg = map[string]any{
+	"type":        "string",
+	"description": `Session name, prefixed with "host:" for a remote host, e.g. "fix-tests" or "bay9:fix-tests"`,
+}
+
+var tools = []tool{
+	{
+		Name:        "list_sessions",
+		Description: "List running Claude sessions (crabs) on all hosts with their status, mode, last action, git changes, pending prompt or menu and number of queued messages.",
+		InputSchema: object(nil),
+		call:        (*Server).listSessions,
+	},
+	{
+		Name:        "new_session",
+		Description: "Start a new Claude session in tmux, optionally sending it a first message once Claude is ready.",
+		InputSchema: object(map[string]any{
+			"name":            map[string]any{"type": "string", "description": "Crab name: letters, digits, hyphens and underscores"},
+			"host":            map[string]any{"type": "string", "description": "Remote host nickname from the config, omit for this machine"},
+			"dir":             map[string]any{"type": "string", "description": "Working directory, ~/ expands on the session's host"},
+			"message":         map[string]any{"type": "string", "description": "First message to send"},
+			"template":        map[string]any{"type": "string", "description": "Template from the config supplying host, dir, claude settings and message; message becomes its {{.Arg}}"},
+			"permission_mode": map[string]any{"type": "string", "description": "Claude permission mode: default, plan, acceptEdits or bypass"},
+			"model":           map[string]any{"type": "string", "description": "Claude model"},
+			"claude_args":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Extra arguments passed to claude"},
+			"worktree":        map[string]any{"type": "boolean", "description": "Start in a new git worktree of dir's repo"},
+			"worktree_branch": map[string]any{"type": "string", "description": "Branch for the worktree, default the crab name"},
+			"autoforward":     map[string]any{"type": "boolean", "description": "Send \"continue\" automatically when the session stops"},
+		}, "name"),
+		call: (*Server).newSession,
+	},
+	{
+		Name:        "send_message",
+		Description: "Type a message into a session and press Enter. With queue, the message is held until the session is idle instead.",
+		InputSchema: object(map[string]any{
+			"session": sessionArg,
+			"text":    map[string]any{"type": "string", "description": "Message to send"},
+			"queue":   map[string]any{"type": "boolean", "description": "Deliver once the session is idle (needs the TUI or crabctl daemon running)"},
+		}, "session", "text"),
+		call: (*Server).sendMessage,
+	},
+	{
+		Name:        "wait_for_status",
+		Description: "Wait until a session reaches one of the given statuses, it exits, or the timeout passes. Returns the session as last seen.",
+		InputSchema: object(map[string]any{
+			"session": sessionArg,
+			"status": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "enum": []string{"waiting", "task-done", "permission", "confirm", "running"}},
+				"description": "Statuses to wait for, default: any status that needs attention (waiting, task-done, permission, confirm)",
+			},
+			"timeout_seconds": map[string]any{"type": "integer", "description": "Give up after this many seconds, default 300, at most 3600"},
+		}, "session"),
+		call: (*Server).waitForStatus,
+	},
+	{
+		Name:        "read_transcript",
+		Description: "Read the conversation of a running session, or of a past one by crab name or UUID prefix. Returns the last entries, oldest first.",
+		InputSchema: object(map[string]any{
+			"session": sessionArg,
+			"last":    map[string]any{"type": "integer", "description": "Number of entries to return, default 50, 0 for all"},
+			"tools":   map[string]any{"type": "boolean", "description": "Include full tool inputs and outputs instead of one-line summaries"},
+		}, "session"),
+		call: (*Server).readTranscript,
+	},
+	{
+		Name:        "answer_prompt",
+		Description: "Answer the numbered menu a session shows, such as a permission prompt or plan approval. A number picks that option; other text picks the free-text option and types it.",
+		InputSchema: object(map[string]any{
+			"session": sessionArg,
+			"answer":  map[string]any{"type": "string", "description": "Option number, or text for the menu's free-text option"},
+		}, "session", "answer"),
+		call: (*Server).answerPrompt,
+	},
+	{
+		Name:        "kill_session",
+		Description: "Kill a session. Its Claude session is recorded so it can be resumed later.",
+		InputSchema: object(map[string]any{
+			"session": sessionArg,
+		}, "session"),
+		call: (*Server).killSession,
+	},
+}
+
+func toolByName(name string) (tool, bool) {
+	for _, t := range tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+// object returns a JSON schema for an object with the given properties.
+func object(properties map[string]any, required ...string) map[string]any {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// sessionInfo is a session as returned by the tools.
+type sessionInfo struct {
+	session.Entry
+	Prompt string    `json:"prompt,omitempty"` // pending permission prompt
+	Menu   *menuInfo `json:"menu,omitempty"`   // numbered menu on screen, answer with answer_prompt
+	Queued int       `json:"queued"`           // messages waiting for the session to be idle
+}
+
+type menuInfo struct {
+	Question string       `json:"question,omitempty"`
+	Options  []menuOption `json:"options"`
+}
+
+type menuOption struct {
+	Number int    `json:"number"`
+	Label  string `json:"label"`
+}
+
+func newSessionInfo(sess session.Session, depths map[string]int) sessionInfo {
+	info := sessionInfo{Entry: session.NewEntry(sess), Queued: depths[state.QueueKey(sess.Host, sess.FullName)]}
+	var question string
+	var options []session.MenuOption
+	switch {
+	case sess.Prompt != nil:
+		info.Prompt = sess.Prompt.Summary()
+		question, options = sess.Prompt.Question, sess.Prompt.Options
+	case sess.Menu != nil:
+		question, options = sess.Menu.Question, sess.Menu.Options
+	}
+	if len(options) > 0 {
+		info.Menu = &menuInfo{Question: question}
+		for _, o := range options {
+			info.Menu.Options = append(info.Menu.Options, menuOption{Number: o.Number, Label: o.Label})
+		}
+	}
+	return info
+}
+
+func (s *Server) queueDepths() map[string]int {
+	if s.store == nil {
+		return nil
+	}
+	depths, _ := s.store.QueueDepths()
+	return depths
+}
+
+func (s *Server) listSessions(ctx context.Context, raw json.RawMessage) (any, error) {
+	hooks, _ := s.store.LoadHookEvents()
+	sessions, err := session.ListAll(s.executors, hooks)
+	if len(sessions) == 0 && err != nil {
+		return nil, err
+	}
+	depths := s.queueDepths()
+	out := struct {
+		Sessions []sessionInfo `json:"sessions"`
+		Errors   string        `json:"errors,omitempty"` // hosts that couldn't be listed
+	}{Sessions: make([]sessionInfo, 0, len(sessions))}
+	for _, sess := range sessions {
+		out.Sessions = append(out.Sessions, newSessionInfo(sess, depths))
+	}
+	if err != nil {
+		out.Errors = err.Error()
+	}
+	return out, nil
+}
+
+func (s *Server) newSession(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Name           string   `json:"name"`
+		Host           string   `json:"host"`
+		Dir            string   `json:"dir"`
+		Template       string   `json:"template"`
+		Message        string   `json:"message"`
+		PermissionMode string   `json:"permission_mode"`
+		Model          string   `json:"model"`
+		ClaudeArgs     []string `json:"claude_args"`
+		Worktree       bool     `json:"worktree"`
+		WorktreeBranch string   `json:"worktree_branch"`
+		AutoForward    bool     `json:"autoforward"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	created, err := session.Create(s.executors, s.store, session.CreateOptions{
+		Name:     args.Name,
+		Host:     args.Host,
+		Dir:      args.Dir,
+		Template: args.Template,
+		Message:  args.Message,
+		Claude: config.ClaudeConfig{
+			PermissionMode: args.PermissionMode,
+			Model:          args.Model,
+			Args:           args.ClaudeArgs,
+		},
+		Worktree:       args.Worktree,
+		WorktreeBranch: args.WorktreeBranch,
+		AutoForward:    args.AutoForward,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ex, fullName := created.Executor, created.FullName
+
+	out := struct {
+		Session     string `json:"session"`
+		Dir         string `json:"dir"`
+		Worktree    string `json:"worktree,omitempty"`
+		MessageSent bool   `json:"message_sent"`
+		Warning     string `json:"warning,omitempty"`
+	}{Session: session.Label(ex.HostName(), args.Name), Dir: created.Dir}
+	if created.Worktree != nil {
+		out.Worktree = created.Worktree.Path
+	}
+	if created.Message != "" {
+		if err := session.WaitForPrompt(ex, fullName); err != nil {
+			out.Warning = fmt.Sprintf("%v (session created but message not sent)", err)
+		} else if err := session.SendMessage(ex, fullName, created.Message); err != nil {
+			out.Warning = fmt.Sprintf("failed to send message: %v", err)
+		} else {
+			out.MessageSent = true
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) sendMessage(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Session string `json:"session"`
+		Text    string `json:"text"`
+		Queue   bool   `json:"queue"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Text == "" {
+		return nil, errors.New("text is required")
+	}
+	ex, fullName, err := session.Resolve(s.executors, args.Session)
+	if err != nil {
+		return nil, err
+	}
+	if args.Queue {
+		if s.store == nil {
+			return nil, errors.New("queueing needs the state database")
+		}
+		n, err := s.store.EnqueueMessage(fullName, ex.HostName(), args.Text)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"sent": false, "queued": n}, nil
+	}
+	if err := session.SendMessage(ex, fullName, args.Text); err != nil {
+		return nil, err
+	}
+	_ = s.store.RecordEvent(state.Event{Name: fullName, Host: ex.HostName(), Kind: state.EventSend, Detail: args.Text})
+	return map[string]any{"sent": true}, nil
+}
+
+func (s *Server) waitForStatus(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Session        string   `json:"session"`
+		Status         []string `json:"status"`
+		TimeoutSeconds int      `json:"timeout_seconds"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	want := map[session.Status]bool{}
+	for _, name := range args.Status {
+		st, err := session.ParseStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		want[st] = true
+	}
+	if len(want) == 0 {
+		want = map[session.Status]bool{session.Waiting: true, session.TaskDone: true, session.Permission: true, session.Confirm: true}
+	}
+	timeout := defaultWaitTimeout
+	if args.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(args.TimeoutSeconds)*time.Second, maxWaitTimeout)
+	}
+	ex, fullName, err := session.Resolve(s.executors, args.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		Reached  bool         `json:"reached"`
+		Vanished bool         `json:"vanished"` // the session exited
+		TimedOut bool         `json:"timed_out"`
+		Session  *sessionInfo `json:"session,omitempty"`
+	}
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	var last *sessionInfo
+	for {
+		hooks, _ := s.store.LoadHookEvents()
+		sessions, err := session.ListExecutor(ex, hooks)
+		if err == nil {
+			found := false
+			for _, sess := range sessions {
+				if sess.FullName != fullName {
+					continue
+				}
+				found = true
+				info := newSessionInfo(sess, s.queueDepths())
+				last = &info
+				if want[sess.Status] {
+					return result{Reached: true, Session: last}, nil
+				}
+			}
+			if !found {
+				return result{Vanished: true, Session: last}, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return result{TimedOut: true, Session: last}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// transcriptEntry is a transcript entry as returned by read_transcript.
+type transcriptEntry struct {
+	Kind    string    `json:"kind"` // user, assistant, tool_call or tool_result
+	Time    time.Time `json:"time,omitzero"`
+	Text    string    `json:"text"`
+	IsError bool      `json:"is_error,omitempty"`
+}
+
+var entryKinds = map[session.EntryKind]string{
+	session.EntryUser:       "user",
+	session.EntryAssistant:  "assistant",
+	session.EntryToolCall:   "tool_call",
+	session.EntryToolResult: "tool_result",
+}
+
+func (s *Server) readTranscript(ctx context.Context, raw json.RawMessage) (any, error) {
+	args := struct {
+		Session string `json:"session"`
+		Last    *int   `json:"last"`
+		Tools   bool   `json:"tools"`
+	}{}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	fsys, workDir, uuid, err := s.transcriptFile(args.Session)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := session.ReadTranscript(fsys, workDir, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", uuid, err)
+	}
+
+	total := len(entries)
+	last := defaultTranscript
+	if args.Last != nil {
+		last = *args.Last
+	}
+	if last > 0 && len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	out := struct {
+		UUID    string            `json:"uuid"`
+		Dir     string            `json:"dir"`
+		Total   int               `json:"total"` // entries in the whole conversation
+		Entries []transcriptEntry `json:"entries"`
+	}{UUID: uuid, Dir: workDir, Total: total, Entries: make([]transcriptEntry, 0, len(entries))}
+	for _, e := range entries {
+		text := e.Text
+		if !args.Tools && (e.Kind == session.EntryToolCall || e.Kind == session.EntryToolResult) {
+			text = e.Summary
+		}
+		out.Entries = append(out.Entries, transcriptEntry{Kind: entryKinds[e.Kind], Time: e.Time, Text: text, IsError: e.IsError})
+	}
+	return out, nil
+}
+
+// transcriptFile locates the Claude session file of a running session or,
+// failing that, of a past one matched by crab name or UUID prefix.
+func (s *Server) transcriptFile(arg string) (tmux.FileSystem, string, string, error) {
+	if ex, fullName, err := session.Resolve(s.executors, arg); err == nil {
+		workDir, uuid, err := session.TranscriptFile(ex, s.store, fullName)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return ex, workDir, uuid, nil
+	}
+
+	if s.store == nil {
+		return nil, "", "", fmt.Errorf("session %q not found", arg)
+	}
+	past, err := session.LoadResumable(s.store, s.executors, true)
+	if err != nil {
+		return nil, "", "", err
+	}
+	var matches []session.ClaudeSession
+	for _, cs := range past {
+		name := cs.Name
+		if ex, err := session.FindExecutor(s.executors, cs.Host); err == nil {
+			name = strings.TrimPrefix(name, ex.SessionPrefix())
+		}
+		if cs.Name != "" && (cs.Name == arg || name == arg) {
+			matches = []session.ClaudeSession{cs}
+			break
+		}
+		if strings.HasPrefix(cs.UUID, arg) {
+			matches = append(matches, cs)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, "", "", fmt.Errorf("session %q not found", arg)
+	case len(matches) > 1:
+		return nil, "", "", fmt.Errorf("%d past sessions match %q, be more specific", len(matches), arg)
+	}
+	ex, err := session.FindExecutor(s.executors, matches[0].Host)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return ex, matches[0].ProjectDir, matches[0].UUID, nil
+}
+
+func (s *Server) answerPrompt(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Session string `json:"session"`
+		Answer  string `json:"answer"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Answer) == "" {
+		return nil, errors.New("answer is required")
+	}
+	ex, fullName, err := session.Resolve(s.executors, args.Session)
+	if err != nil {
+		return nil, err
+	}
+	opt, err := session.AnswerMenu(ex, fullName, args.Answer)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"picked": menuOption{Number: opt.Number, Label: opt.Label}}, nil
+}
+
+func (s *Server) killSession(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Session string `json:"session"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	ex, fullName, err := session.Resolve(s.executors, args.Session)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.Kill(ex, s.store, fullName); err != nil {
+		return nil, fmt.Errorf("failed to kill session: %w", err)
+	}
+	return map[string]bool{"killed": true}, nil
+}
@@ -0,0 +1,234 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// DefaultAutoForward is the autoforward policy used when neither the
+// config file nor the session sets one. The message spells TASK DONE with
+// an underscore so echoing it back doesn't count as finishing.
+var DefaultAutoForward = config.AutoForwardConfig{
+	Message: `Continue working until done. Say "TASK_DONE!" (swap _ for space) if you really think you're done.`,
+	Delay:   10 * time.Second,
+	Max:     5,
+}
+
+// LoadAutoForwardDefaults returns the built-in autoforward policy with the
+// "autoforward" section of the config file applied.
+func LoadAutoForwardDefaults() config.AutoForwardConfig {
+	p := DefaultAutoForward
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		p = p.Merge(cfg.AutoForward)
+	}
+	return p
+}
+
+// AutoForwardable reports whether a session in status s can be forwarded
+// under policy p: it is waiting, or said TASK DONE and p doesn't stop there.
+func AutoForwardable(p config.AutoForwardConfig, s Status) bool {
+	return s == Waiting || (s == TaskDone && !p.StopsOnTaskDone())
+}
+
+// ParseAutoForwardPolicy builds a session policy from user input. Empty
+// fields keep the defaults; delay is a Go duration or a number of seconds
+// and stopOnDone is yes/no.
+func ParseAutoForwardPolicy(message, delay, maxCount, stopOnDone string) (config.AutoForwardConfig, error) {
+	p := config.AutoForwardConfig{Message: strings.TrimSpace(message)}
+	if delay = strings.TrimSpace(delay); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			secs, serr := strconv.Atoi(delay)
+			if serr != nil {
+				return p, fmt.Errorf("invalid delay %q: use e.g. 30s or 2m", delay)
+			}
+			d = time.Duration(secs) * time.Second
+		}
+		if d <= 0 {
+			return p, fmt.Errorf("delay must be positive")
+		}
+		p.Delay = d
+	}
+	if maxCount = strings.TrimSpace(maxCount); maxCount != "" {
+		n, err := strconv.Atoi(maxCount)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("invalid max %q: use a positive number", maxCount)
+		}
+		p.Max = n
+	}
+	switch strings.ToLower(strings.TrimSpace(stopOnDone)) {
+	case "":
+	case "y", "yes", "true", "on":
+		stop := true
+		p.StopOnTaskDone = &stop
+	case "n", "no", "false", "off":
+		stop := false
+		p.StopOnTaskDone = &stop
+	default:
+		return p, fmt.Errorf("invalid stop on TASK DONE %q: use yes or no", stopOnDone)
+	}
+	return p, nil
+}
+
+// AutoForwarder tracks which sessions have autoforward enabled, how long
+// they have been waiting and how many forwards they got in a row. The TUI
+// and `crabctl daemon` drive it from their poll loops. Sessions are told
+// apart by name and host, keyed by state.QueueKey.
+type AutoForwarder struct {
+	Defaults config.AutoForwardConfig
+	enabled  map[string]bool                     // key -> enabled
+	policies map[string]config.AutoForwardConfig // key -> policy set with `crabctl set`
+	since    map[string]time.Time                // key -> when first seen waiting
+	count    map[string]int                      // key -> consecutive forwards sent
+}
+
+// NewAutoForwarder returns an AutoForwarder using defaults for sessions
+// without their own policy.
+func NewAutoForwarder(defaults config.AutoForwardConfig) *AutoForwarder {
+	return &AutoForwarder{
+		Defaults: defaults,
+		enabled:  make(map[string]bool),
+		policies: make(map[string]config.AutoForwardConfig),
+		since:    make(map[string]time.Time),
+		count:    make(map[string]int),
+	}
+}
+
+// Enabled reports whether autoforward is on for a session.
+func (a *AutoForwarder) Enabled(fullName, host string) bool {
+	return a.enabled[state.QueueKey(host, fullName)]
+}
+
+// SetEnabled turns autoforward on or off for a session. Turning it off
+// resets the session's wait timer and count.
+func (a *AutoForwarder) SetEnabled(fullName, host string, on bool) {
+	key := state.QueueKey(host, fullName)
+	if on {
+		a.enabled[key] = true
+		return
+	}
+	delete(a.enabled, key)
+	a.forget(key)
+}
+
+// OwnPolicy returns what a session's policy sets itself, without defaults.
+func (a *AutoForwarder) OwnPolicy(fullName, host string) config.AutoForwardConfig {
+	return a.policies[state.QueueKey(host, fullName)]
+}
+
+// SetPolicy replaces a session's own policy.
+func (a *AutoForwarder) SetPolicy(fullName, host string, p config.AutoForwardConfig) {
+	key := state.QueueKey(host, fullName)
+	if p.IsZero() {
+		delete(a.policies, key)
+	} else {
+		a.policies[key] = p
+	}
+}
+
+// Policy returns a session's policy over the defaults.
+func (a *AutoForwarder) Policy(fullName, host string) config.AutoForwardConfig {
+	return a.Defaults.Merge(a.policies[state.QueueKey(host, fullName)])
+}
+
+// Remaining returns how many forwards a session has left before it next
+// runs on its own, and its maximum.
+func (a *AutoForwarder) Remaining(fullName, host string) (int, int) {
+	limit := a.Policy(fullName, host).Max
+	return max(0, limit-a.count[state.QueueKey(host, fullName)]), limit
+}
+
+// Sync reloads enabled sessions and policies from the store, which
+// `crabctl set` may have changed. Tracking of unchanged sessions is kept.
+func (a *AutoForwarder) Sync(store *state.Store) {
+	if store == nil {
+		return
+	}
+	if policies, err := store.LoadAutoForwardPolicies(); err == nil {
+		a.policies = policies
+	}
+	enabled, err := store.LoadAllAutoForward()
+	if err != nil {
+		return
+	}
+	for key := range a.enabled {
+		if !enabled[key] {
+			a.forget(key)
+		}
+	}
+	a.enabled = enabled
+}
+
+// Sent records a forward to a session.
+func (a *AutoForwarder) Sent(fullName, host string) {
+	a.count[state.QueueKey(host, fullName)]++
+}
+
+func (a *AutoForwarder) forget(key string) {
+	delete(a.since, key)
+	delete(a.count, key)
+}
+
+// Due updates wait tracking from a fresh listing of all sessions and
+// returns those whose wait exceeded their policy's delay and still have
+// forwards left, restarting their timers. Sessions for which skip returns
+// true (e.g. with queued messages) are tracked but not returned.
+func (a *AutoForwarder) Due(sessions []Session, now time.Time, skip func(Session) bool) []Session {
+	var due []Session
+	active := make(map[string]bool)
+	for _, s := range sessions {
+		key := state.QueueKey(s.Host, s.FullName)
+		active[key] = true
+		if !a.enabled[key] {
+			continue
+		}
+		policy := a.Policy(s.FullName, s.Host)
+
+		if !AutoForwardable(policy, s.Status) {
+			// Not waiting: reset the timer, and the count once it runs again
+			delete(a.since, key)
+			if s.Status == Running {
+				a.count[key] = 0
+			}
+			continue
+		}
+		since, ok := a.since[key]
+		if !ok {
+			a.since[key] = now
+			continue
+		}
+		if (skip != nil && skip(s)) || now.Sub(since) < policy.Delay || a.count[key] >= policy.Max {
+			continue
+		}
+		due = append(due, s)
+		// Wait another full delay before the next forward
+		a.since[key] = now
+	}
+
+	// Clean up tracking for sessions that no longer exist
+	for key := range a.since {
+		if !active[key] {
+			a.forget(key)
+		}
+	}
+	return due
+}
+
+// Forward sends a session its autoforward message after re-capturing the
+// pane to check it is still waiting. Returns false if it was not.
+func Forward(ex tmux.Executor, fullName string, p config.AutoForwardConfig) (bool, error) {
+	output, err := ex.CapturePaneOutput(fullName, 25)
+	if err == nil && !AutoForwardable(p, DetectStatus(output)) {
+		return false, nil
+	}
+	if err := ex.SendKeys(fullName, p.Message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+)
+
+func TestParseAutoForwardPolicy(t *testing.T) {
+	tests := []struct {
+		name                      string
+		message, delay, max, stop string
+		wantDelay                 time.Duration
+		wantMax                   int
+		wantStop                  bool
+		err                       bool
+	}{
+		{name: "all defaults", wantStop: true},
+		{name: "duration", delay: "1m30s", wantDelay: 90 * time.Second, wantStop: true},
+		{name: "seconds", delay: "45", max: "3", wantDelay: 45 * time.Second, wantMax: 3, wantStop: true},
+		{name: "keep going after done", stop: "no", wantStop: false},
+		{name: "bad delay", delay: "soon", err: true},
+		{name: "zero max", max: "0", err: true},
+		{name: "bad stop", stop: "maybe", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseAutoForwardPolicy(tt.message, tt.delay, tt.max, tt.stop)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if p.Delay != tt.wantDelay || p.Max != tt.wantMax || p.StopsOnTaskDone() != tt.wantStop {
+				t.Errorf("got %+v, want delay %v max %d stop %v", p, tt.wantDelay, tt.wantMax, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestAutoForwardable(t *testing.T) {
+	p, _ := ParseAutoForwardPolicy("", "", "", "no")
+	tests := []struct {
+		status Status
+		stop   bool
+		want   bool
+	}{
+		{Waiting, true, true},
+		{TaskDone, true, false},
+		{TaskDone, false, true},
+		{Running, false, false},
+	}
+	for _, tt := range tests {
+		policy := DefaultAutoForward
+		if !tt.stop {
+			policy = policy.Merge(p)
+		}
+		if got := AutoForwardable(policy, tt.status); got != tt.want {
+			t.Errorf("AutoForwardable(stop=%v, %v) = %v, want %v", tt.stop, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestAutoForwarderDue(t *testing.T) {
+	a := NewAutoForwarder(DefaultAutoForward)
+	a.SetEnabled("crab-a", "", true)
+	a.SetPolicy("crab-a", "", config.AutoForwardConfig{Max: 2})
+	// crab-a on bay3 is another session, with autoforward off
+	waiting := []Session{
+		{FullName: "crab-a", Status: Waiting},
+		{FullName: "crab-a", Host: "bay3", Status: Waiting},
+		{FullName: "crab-b", Status: Waiting},
+	}
+	start := time.Now()
+
+	if due := a.Due(waiting, start, nil); len(due) != 0 {
+		t.Fatalf("first sighting: due = %v", due)
+	}
+	if due := a.Due(waiting, start.Add(5*time.Second), nil); len(due) != 0 {
+		t.Fatalf("before delay: due = %v", due)
+	}
+	queued := func(Session) bool { return true }
+	if due := a.Due(waiting, start.Add(11*time.Second), queued); len(due) != 0 {
+		t.Fatalf("skipped: due = %v", due)
+	}
+	for i := 1; i <= 3; i++ {
+		due := a.Due(waiting, start.Add(time.Duration(11*i+11)*time.Second), nil)
+		if want := i <= 2; (len(due) == 1) != want {
+			t.Fatalf("forward %d: due = %v, want due %v", i, due, want)
+		}
+		if len(due) == 1 {
+			if due[0].FullName != "crab-a" || due[0].Host != "" {
+				t.Fatalf("forwarded %s, which has autoforward off", due[0].Label())
+			}
+			a.Sent("crab-a", "")
+		}
+	}
+	if left, limit := a.Remaining("crab-a", ""); left != 0 || limit != 2 {
+		t.Errorf("Remaining = %d/%d, want 0/2", left, limit)
+	}
+
+	// Running again resets the count
+	a.Due([]Session{{FullName: "crab-a", Status: Running}}, start.Add(time.Minute), nil)
+	if left, _ := a.Remaining("crab-a", ""); left != 2 {
+		t.Errorf("after running: %d left, want 2", left)
+	}
+}
@@ -3,11 +3,13 @@ package session
 import (
 	"bufio"
 	"encoding/json"
-	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/simon/crabctl/internal/tmux"
 )
 
 // ClaudeSession represents a past Claude Code conversation that can be resumed.
@@ -18,47 +20,128 @@ type ClaudeSession struct {
 	ModTime      time.Time
 	FirstMessage string // first user message, truncated
 	Killed       bool   // true if explicitly killed via crabctl (false = lost/crashed)
+	Tracked      bool   // true if crabctl has a record of this session in its state DB
+	Host         string // host nickname the session file lives on, empty for local
+	ClaudeBin    string // claude binary the crab was started with, if not the default
+	ClaudeFlags  string // claude flags the crab was started with (CRABCTL_FLAGS)
 	encodedDir   string // internal: encoded dir name for file lookup
 }
 
+// State describes where a resumable session came from: "killed" (via crabctl),
+// "lost" (crab disappeared, e.g. Ctrl+C or crash) or "never-crabbed"
+// (a Claude conversation crabctl never managed).
+func (cs ClaudeSession) State() string {
+	switch {
+	case !cs.Tracked:
+		return "never-crabbed"
+	case cs.Killed:
+		return "killed"
+	default:
+		return "lost"
+	}
+}
+
+// MergeClaudeSessions combines sessions recorded by crabctl (tracked) with
+// conversations found on disk. Disk entries matching a tracked UUID take the
+// crab name and killed state from it; tracked sessions whose file is not in
+// onDisk are kept as-is. The result is sorted most recent first.
+func MergeClaudeSessions(tracked, onDisk []ClaudeSession) []ClaudeSession {
+	byUUID := make(map[string]ClaudeSession, len(tracked))
+	for _, t := range tracked {
+		byUUID[t.UUID] = t
+	}
+
+	merged := make([]ClaudeSession, 0, len(tracked)+len(onDisk))
+	seen := make(map[string]bool)
+	for _, d := range onDisk {
+		if t, ok := byUUID[d.UUID]; ok {
+			d.Name = t.Name
+			d.Killed = t.Killed
+			d.Tracked = true
+			d.ClaudeBin = t.ClaudeBin
+			d.ClaudeFlags = t.ClaudeFlags
+			if d.FirstMessage == "" {
+				d.FirstMessage = t.FirstMessage
+			}
+		}
+		seen[d.UUID] = true
+		merged = append(merged, d)
+	}
+	for _, t := range tracked {
+		if !seen[t.UUID] {
+			merged = append(merged, t)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].ModTime.After(merged[j].ModTime)
+	})
+	return merged
+}
+
+var nameCharRe = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SuggestName returns a crab name for a session: its existing crab name
+// (without prefix), or one derived from the project directory and UUID
+// for conversations crabctl never managed.
+func SuggestName(cs ClaudeSession, prefix string) string {
+	if name := strings.TrimPrefix(cs.Name, prefix); name != "" {
+		return name
+	}
+	base := nameCharRe.ReplaceAllString(filepath.Base(cs.ProjectDir), "-")
+	base = strings.Trim(base, "-")
+	if base == "" || base == "." {
+		base = "claude"
+	}
+	short := cs.UUID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	return base + "-" + short
+}
+
+// claudeProjectsDir returns ~/.claude/projects on the given filesystem.
+func claudeProjectsDir(fsys tmux.FileSystem) (string, error) {
+	home, err := fsys.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude", "projects"), nil
+}
+
 // ListRecentClaudeSessions scans ~/.claude/projects/ for recent session files.
 // Returns up to limit sessions sorted by most recently modified first.
-func ListRecentClaudeSessions(limit int) []ClaudeSession {
-	home, err := os.UserHomeDir()
+func ListRecentClaudeSessions(fsys tmux.FileSystem, limit int) []ClaudeSession {
+	projectsDir, err := claudeProjectsDir(fsys)
 	if err != nil {
 		return nil
 	}
-	projectsDir := filepath.Join(home, ".claude", "projects")
 
-	projectDirs, err := os.ReadDir(projectsDir)
+	projectDirs, err := fsys.ReadDir(projectsDir)
 	if err != nil {
 		return nil
 	}
 
 	var all []ClaudeSession
 	for _, pd := range projectDirs {
-		if !pd.IsDir() {
+		if !pd.IsDir {
 			continue
 		}
-		dirPath := filepath.Join(projectsDir, pd.Name())
-		entries, err := os.ReadDir(dirPath)
+		dirPath := filepath.Join(projectsDir, pd.Name)
+		entries, err := fsys.ReadDir(dirPath)
 		if err != nil {
 			continue
 		}
 		for _, e := range entries {
-			if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			if e.IsDir || !strings.HasSuffix(e.Name, ".jsonl") {
 				continue
 			}
-			info, err := e.Info()
-			if err != nil {
-				continue
-			}
-			uuid := strings.TrimSuffix(e.Name(), ".jsonl")
+			uuid := strings.TrimSuffix(e.Name, ".jsonl")
 			all = append(all, ClaudeSession{
 				UUID:       uuid,
-				ProjectDir: pd.Name(), // placeholder, replaced by readSessionMeta
-				ModTime:    info.ModTime(),
-				encodedDir: pd.Name(),
+				ProjectDir: pd.Name, // placeholder, replaced by readSessionMeta
+				ModTime:    e.ModTime,
+				encodedDir: pd.Name,
 			})
 		}
 	}
@@ -74,7 +157,7 @@ func ListRecentClaudeSessions(limit int) []ClaudeSession {
 
 	// Read metadata (cwd + first message) for the top results
 	for i := range all {
-		meta := readSessionMeta(
+		meta := readSessionMeta(fsys,
 			filepath.Join(projectsDir, all[i].encodedDir, all[i].UUID+".jsonl"),
 		)
 		all[i].FirstMessage = meta.FirstMessage
@@ -94,8 +177,8 @@ type sessionMeta struct {
 }
 
 // readSessionMeta reads the cwd, first user message, and start time from a JSONL session file.
-func readSessionMeta(path string) sessionMeta {
-	f, err := os.Open(path)
+func readSessionMeta(fsys tmux.FileSystem, path string) sessionMeta {
+	f, err := fsys.OpenFile(path)
 	if err != nil {
 		return sessionMeta{}
 	}
@@ -190,20 +273,18 @@ func extractContent(raw json.RawMessage) string {
 // timestamp matching, and modification time fallbacks.
 // excludeUUIDs contains UUIDs already claimed by other sessions — these files
 // are skipped entirely (not read from disk).
-func FindSessionUUID(workDir string, sessionStart time.Time, paneContent string, excludeUUIDs map[string]bool) (uuid string, firstMsg string) {
+func FindSessionUUID(fsys tmux.FileSystem, workDir string, sessionStart time.Time, paneContent string, excludeUUIDs map[string]bool) (uuid string, firstMsg string) {
 	if workDir == "" {
 		return "", ""
 	}
 
-	home, err := os.UserHomeDir()
+	projectsDir, err := claudeProjectsDir(fsys)
 	if err != nil {
 		return "", ""
 	}
+	projectDir := filepath.Join(projectsDir, encodeProjectDir(workDir))
 
-	encoded := encodeProjectDir(workDir)
-	projectDir := filepath.Join(home, ".claude", "projects", encoded)
-
-	entries, err := os.ReadDir(projectDir)
+	entries, err := fsys.ReadDir(projectDir)
 	if err != nil {
 		return "", ""
 	}
@@ -216,18 +297,14 @@ func FindSessionUUID(workDir string, sessionStart time.Time, paneContent string,
 	}
 	var files []fileEntry
 	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+		if e.IsDir || !strings.HasSuffix(e.Name, ".jsonl") {
 			continue
 		}
-		u := strings.TrimSuffix(e.Name(), ".jsonl")
+		u := strings.TrimSuffix(e.Name, ".jsonl")
 		if excludeUUIDs[u] {
 			continue
 		}
-		info, err := e.Info()
-		if err != nil {
-			continue
-		}
-		files = append(files, fileEntry{uuid: u, modTime: info.ModTime()})
+		files = append(files, fileEntry{uuid: u, modTime: e.ModTime})
 	}
 
 	// Sort newest first
@@ -251,7 +328,7 @@ func FindSessionUUID(workDir string, sessionStart time.Time, paneContent string,
 
 	candidates := make([]candidate, 0, len(files))
 	for _, f := range files {
-		meta := readSessionMeta(filepath.Join(projectDir, f.uuid+".jsonl"))
+		meta := readSessionMeta(fsys, filepath.Join(projectDir, f.uuid+".jsonl"))
 		candidates = append(candidates, candidate{
 			uuid:     f.uuid,
 			firstMsg: meta.FirstMessage,
@@ -273,7 +350,7 @@ func FindSessionUUID(workDir string, sessionStart time.Time, paneContent string,
 		for i := range candidates {
 			c := &candidates[i]
 			path := filepath.Join(projectDir, c.uuid+".jsonl")
-			snippets := readLastUserMessages(path, 3)
+			snippets := readLastUserMessages(fsys, path, 3)
 			score := 0
 			for _, s := range snippets {
 				if strings.Contains(paneContent, s) {
@@ -344,8 +421,8 @@ func FindSessionUUID(workDir string, sessionStart time.Time, paneContent string,
 
 // readLastUserMessages reads the last n user messages from a JSONL session
 // file and returns normalized text snippets suitable for substring matching.
-func readLastUserMessages(path string, n int) []string {
-	f, err := os.Open(path)
+func readLastUserMessages(fsys tmux.FileSystem, path string, n int) []string {
+	f, err := fsys.OpenFile(path)
 	if err != nil {
 		return nil
 	}
@@ -389,20 +466,18 @@ func readLastUserMessages(path string, n int) []string {
 
 // ReadSessionPreview reads a JSONL session file and returns a formatted
 // conversation preview showing the last maxMessages user/assistant messages.
-func ReadSessionPreview(workDir, uuid string, maxMessages int) string {
+func ReadSessionPreview(fsys tmux.FileSystem, workDir, uuid string, maxMessages int) string {
 	if uuid == "" {
 		return ""
 	}
 
-	home, err := os.UserHomeDir()
+	projectsDir, err := claudeProjectsDir(fsys)
 	if err != nil {
 		return ""
 	}
+	path := filepath.Join(projectsDir, encodeProjectDir(workDir), uuid+".jsonl")
 
-	encoded := encodeProjectDir(workDir)
-	path := filepath.Join(home, ".claude", "projects", encoded, uuid+".jsonl")
-
-	f, err := os.Open(path)
+	f, err := fsys.OpenFile(path)
 	if err != nil {
 		return ""
 	}
@@ -486,19 +561,19 @@ func encodeProjectDir(dir string) string {
 
 // SessionFileModTime returns the modification time of a specific session file.
 // Much cheaper than scanning the entire directory — just one stat call.
-func SessionFileModTime(workDir, uuid string) time.Time {
+func SessionFileModTime(fsys tmux.FileSystem, workDir, uuid string) time.Time {
 	if workDir == "" || uuid == "" {
 		return time.Time{}
 	}
-	home, err := os.UserHomeDir()
+	projectsDir, err := claudeProjectsDir(fsys)
 	if err != nil {
 		return time.Time{}
 	}
-	path := filepath.Join(home, ".claude", "projects", encodeProjectDir(workDir), uuid+".jsonl")
-	info, err := os.Stat(path)
+	path := filepath.Join(projectsDir, encodeProjectDir(workDir), uuid+".jsonl")
+	info, err := fsys.Stat(path)
 	if err != nil {
 		return time.Time{}
 	}
-	return info.ModTime()
+	return info.ModTime
 }
 
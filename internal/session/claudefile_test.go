@@ -0,0 +1,57 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeClaudeSessions(t *testing.T) {
+	now := time.Now()
+	tracked := []ClaudeSession{
+		{Name: "crab-foo", UUID: "aaa", ModTime: now.Add(-time.Hour), Killed: true, Tracked: true},
+		{Name: "crab-gone", UUID: "ccc", ModTime: now.Add(-3 * time.Hour), Tracked: true},
+	}
+	onDisk := []ClaudeSession{
+		{UUID: "bbb", ModTime: now.Add(-2 * time.Hour), ProjectDir: "/src/bar"},
+		{UUID: "aaa", ModTime: now, ProjectDir: "/src/foo"},
+	}
+
+	merged := MergeClaudeSessions(tracked, onDisk)
+
+	want := []struct {
+		uuid, name, state string
+	}{
+		{"aaa", "crab-foo", "killed"},
+		{"bbb", "", "never-crabbed"},
+		{"ccc", "crab-gone", "lost"},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d sessions, want %d", len(merged), len(want))
+	}
+	for i, w := range want {
+		got := merged[i]
+		if got.UUID != w.uuid || got.Name != w.name || got.State() != w.state {
+			t.Errorf("position %d: got (%s, %q, %s), want (%s, %q, %s)",
+				i, got.UUID, got.Name, got.State(), w.uuid, w.name, w.state)
+		}
+	}
+	if merged[0].ProjectDir != "/src/foo" {
+		t.Errorf("disk entry should keep its project dir, got %q", merged[0].ProjectDir)
+	}
+}
+
+func TestSuggestName(t *testing.T) {
+	tests := []struct {
+		cs   ClaudeSession
+		want string
+	}{
+		{ClaudeSession{Name: "crab-foo", UUID: "1234"}, "foo"},
+		{ClaudeSession{UUID: "0f3a9c21-77aa-4bcd", ProjectDir: "/home/u/my.repo"}, "my-repo-0f3a9c21"},
+		{ClaudeSession{UUID: "abc", ProjectDir: ""}, "claude-abc"},
+	}
+	for _, tt := range tests {
+		if got := SuggestName(tt.cs, "crab-"); got != tt.want {
+			t.Errorf("SuggestName(%+v) = %q, want %q", tt.cs, got, tt.want)
+		}
+	}
+}
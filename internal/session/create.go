@@ -0,0 +1,134 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// CreateOptions describes a new session. Fields left empty come from the
+// template, if any, and then from the config file's defaults.
+type CreateOptions struct {
+	Name     string
+	Host     string // "" for the template's host (or local); "local" forces local
+	Dir      string
+	Template string
+	// Message is the initial message, or the template's {{.Arg}} when the
+	// template has a message of its own.
+	Message string
+	// Claude is applied on top of the template's claude settings.
+	Claude config.ClaudeConfig
+	// Worktree starts the session in a new git worktree of Dir's repo, on
+	// WorktreeBranch (default Name).
+	Worktree       bool
+	WorktreeBranch string
+	AutoForward    bool
+}
+
+// Created is a session started by Create.
+type Created struct {
+	Executor tmux.Executor
+	FullName string
+	Dir      string
+	Message  string          // initial message still to be sent, if any
+	Worktree *state.Worktree // nil unless CreateOptions.Worktree
+}
+
+// Create starts a Claude session and records it in store (if not nil):
+// the new event, autoforward and its worktree. It doesn't send the initial
+// message; callers wait for the prompt and send Created.Message themselves.
+// Errors wrap ErrInvalid for bad options, ErrNotFound for unknown hosts and
+// ErrExists when the session is already running.
+func Create(executors []tmux.Executor, store *state.Store, opts CreateOptions) (Created, error) {
+	var c Created
+	if !ValidName(opts.Name) {
+		return c, fmt.Errorf("%w name %q: use only alphanumeric, hyphens, underscores", ErrInvalid, opts.Name)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return c, fmt.Errorf("failed to load config: %w", err)
+	}
+	var tmpl config.Template
+	if opts.Template != "" {
+		if tmpl, err = cfg.Template(opts.Template); err != nil {
+			return c, fmt.Errorf("%w template: %w", ErrInvalid, err)
+		}
+	}
+	host := opts.Host
+	if host == "" {
+		host = tmpl.Host
+	}
+
+	ex, err := FindExecutor(executors, host)
+	if err != nil {
+		return c, err
+	}
+	host = ex.HostName()
+	c.Executor = ex
+	c.FullName = ex.SessionPrefix() + opts.Name
+	if ex.HasSession(c.FullName) {
+		return c, fmt.Errorf("session %q %w", Label(host, opts.Name), ErrExists)
+	}
+
+	if c.Message, err = TemplateMessage(tmpl, opts.Name, opts.Message); err != nil {
+		return c, err
+	}
+	claudeBin, claudeArgs, err := LaunchArgs(host, tmpl.Claude.Merge(opts.Claude))
+	if err != nil {
+		return c, err
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = tmpl.Dir
+	}
+	if c.Dir, err = ExpandWorkDir(ex, dir); err != nil {
+		return c, err
+	}
+
+	// Check out a worktree of dir's repo and start there instead
+	if opts.Worktree {
+		branch := opts.WorktreeBranch
+		if branch == "" {
+			branch = opts.Name
+		}
+		root := cfg.WorktreeRoot
+		if root == "" {
+			root = config.DefaultWorktreeRoot
+		}
+		wt, startDir, err := CreateWorktree(ex, c.Dir, root, opts.Name, branch)
+		if err != nil {
+			return c, fmt.Errorf("failed to create worktree: %w", err)
+		}
+		wt.Name = c.FullName
+		c.Worktree = &wt
+		c.Dir = startDir
+	}
+
+	if err := ex.NewSession(opts.Name, c.Dir, claudeBin, claudeArgs); err != nil {
+		if c.Worktree != nil {
+			_ = RemoveWorktree(ex, *c.Worktree)
+		}
+		return c, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if store != nil {
+		var repoDir string
+		if c.Worktree != nil {
+			repoDir = c.Worktree.RepoDir
+		} else {
+			repoDir = RepoRoot(ex, c.Dir)
+		}
+		_ = store.RecordEvent(state.Event{Name: c.FullName, Host: host, Kind: state.EventNew, Detail: c.Message, WorkDir: c.Dir, RepoDir: repoDir})
+		if opts.AutoForward || tmpl.AutoForward {
+			_ = store.SetAutoForward(c.FullName, host, true)
+		}
+		if c.Worktree != nil {
+			_ = store.SaveWorktree(*c.Worktree)
+		}
+	}
+	return c, nil
+}
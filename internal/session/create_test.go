@@ -0,0 +1,28 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestCreateErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	executors := []tmux.Executor{&tmux.LocalExecutor{}}
+	for _, tc := range []struct {
+		desc string
+		opts CreateOptions
+		want error
+	}{
+		{"bad name", CreateOptions{Name: "a b"}, ErrInvalid},
+		{"unknown host", CreateOptions{Name: "crab-test-create", Host: "bay9"}, ErrNotFound},
+		{"unknown template", CreateOptions{Name: "crab-test-create", Template: "reviewer"}, ErrInvalid},
+		{"bad permission mode", CreateOptions{Name: "crab-test-create", Claude: config.ClaudeConfig{PermissionMode: "yolo"}}, ErrInvalid},
+	} {
+		if _, err := Create(executors, nil, tc.opts); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.desc, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+// Entry is the machine-readable form of a session, as printed by
+// `crabctl list -o json` and returned by the API.
+type Entry struct {
+	Name       string `json:"name"`
+	Host       string `json:"host"`
+	Status     string `json:"status"`
+	Mode       string `json:"mode"`
+	LastAction string `json:"last_action"`
+	GitChanges string `json:"git_changes"`
+	PR         string `json:"pr"`
+	PRURL      string `json:"pr_url,omitempty"`
+	Context    string `json:"context"`
+	Duration   int64  `json:"duration_seconds"`
+	WorkDir    string `json:"work_dir"`
+	Attached   int    `json:"attached"`
+}
+
+// NewEntry returns the Entry for s. Local sessions have host "local".
+func NewEntry(s Session) Entry {
+	host := s.Host
+	if host == "" {
+		host = "local"
+	}
+	return Entry{
+		Name:       s.Name,
+		Host:       host,
+		Status:     s.Status.String(),
+		Mode:       s.Mode,
+		LastAction: s.LastAction,
+		GitChanges: s.GitChanges,
+		PR:         s.PR,
+		PRURL:      s.PRURL,
+		Context:    s.Context,
+		Duration:   int64(s.Duration.Seconds()),
+		WorkDir:    s.WorkDir,
+		Attached:   s.AttachedCount,
+	}
+}
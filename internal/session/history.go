@@ -0,0 +1,197 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/state"
+)
+
+// RecordListing records the changes between two listings of a host in the
+// history: the first status of sessions that appeared, status changes, and
+// sessions that went.
+func RecordListing(store *state.Store, appeared, gone []Session, transitions []Transition, now time.Time) {
+	for _, s := range appeared {
+		_ = store.RecordEvent(state.Event{
+			Name: s.FullName, Host: s.Host, Kind: state.EventStatus,
+			Status: s.Status.String(), Detail: promptSummary(s), WorkDir: s.WorkDir, RepoDir: s.RepoDir, Time: now,
+		})
+	}
+	for _, t := range transitions {
+		s := t.Session
+		_ = store.RecordEvent(state.Event{
+			Name: s.FullName, Host: s.Host, Kind: state.EventStatus,
+			Status: s.Status.String(), Previous: t.Previous.String(), Detail: promptSummary(s), WorkDir: s.WorkDir, RepoDir: s.RepoDir, Time: now,
+		})
+	}
+	for _, s := range gone {
+		_ = store.RecordEvent(state.Event{Name: s.FullName, Host: s.Host, Kind: state.EventEnd, WorkDir: s.WorkDir, RepoDir: s.RepoDir, Time: now})
+	}
+}
+
+// ReconcileHistory brings the history of a host in line with its first
+// listing, when there is no earlier listing to diff against. Sessions whose
+// last recorded status is still open but that aren't listed went while
+// nothing was watching, and listed sessions whose status differs from the
+// recorded one changed meanwhile.
+func ReconcileHistory(store *state.Store, host string, sessions []Session, now time.Time) error {
+	events, err := store.ListEvents("", "", time.Time{})
+	if err != nil {
+		return err
+	}
+	last := make(map[string]state.Event) // name -> last status, kill or end event
+	for _, ev := range events {
+		if ev.Host == host && (ev.Kind == state.EventStatus || ev.Kind == state.EventKill || ev.Kind == state.EventEnd) {
+			last[ev.Name] = ev
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, s := range sessions {
+		listed[s.FullName] = true
+		var previous string
+		if ev, ok := last[s.FullName]; ok && ev.Kind == state.EventStatus {
+			if ev.Status == s.Status.String() {
+				continue
+			}
+			previous = ev.Status
+		}
+		if err := store.RecordEvent(state.Event{
+			Name: s.FullName, Host: s.Host, Kind: state.EventStatus,
+			Status: s.Status.String(), Previous: previous, Detail: promptSummary(s), WorkDir: s.WorkDir, RepoDir: s.RepoDir, Time: now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	var gone []string
+	for name, ev := range last {
+		if ev.Kind == state.EventStatus && !listed[name] {
+			gone = append(gone, name)
+		}
+	}
+	sort.Strings(gone)
+	for _, name := range gone {
+		if err := store.RecordEvent(state.Event{Name: name, Host: host, Kind: state.EventEnd, WorkDir: last[name].WorkDir, RepoDir: last[name].RepoDir, Time: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func promptSummary(s Session) string {
+	if s.Prompt == nil {
+		return ""
+	}
+	return s.Prompt.Summary()
+}
+
+// PruneHistory deletes the events older than the configured retention.
+func PruneHistory(store *state.Store, now time.Time) (int64, error) {
+	var retention time.Duration
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		retention = cfg.History.Retention()
+	} else {
+		retention = config.HistoryConfig{}.Retention()
+	}
+	if retention == 0 {
+		return 0, nil
+	}
+	return store.PruneEvents(now.Add(-retention))
+}
+
+// Span is a stretch of time a session spent in one status.
+type Span struct {
+	EventID int64 // the status event that started it
+	Name    string
+	Host    string
+	Status  string
+	Start   time.Time
+	End     time.Time
+	Open    bool // the session was still in this status at the end
+}
+
+// Duration returns how long the span lasted.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Spans turns the history, oldest first, into the time each session spent
+// in each status. A status lasts until the session's next status, kill or
+// end event; the last status of a session without one lasts until now.
+func Spans(events []state.Event, now time.Time) []Span {
+	var spans []Span
+	open := make(map[string]int) // host:name -> index of its open span
+	for _, ev := range events {
+		if ev.Kind != state.EventStatus && ev.Kind != state.EventKill && ev.Kind != state.EventEnd {
+			continue
+		}
+		key := ev.Host + ":" + ev.Name
+		if i, ok := open[key]; ok {
+			spans[i].End = ev.Time
+			delete(open, key)
+		}
+		if ev.Kind == state.EventStatus {
+			open[key] = len(spans)
+			spans = append(spans, Span{EventID: ev.ID, Name: ev.Name, Host: ev.Host, Status: ev.Status, Start: ev.Time})
+		}
+	}
+	for _, i := range open {
+		spans[i].End = now
+		spans[i].Open = true
+	}
+	return spans
+}
+
+// DescribeEvent returns a one-line description of an event for the
+// history, e.g. "running → permission: Bash(make test)".
+func DescribeEvent(ev state.Event) string {
+	switch ev.Kind {
+	case state.EventNew:
+		desc := "created in " + ev.WorkDir
+		if ev.Detail != "" {
+			desc += ": " + quoteLine(ev.Detail)
+		}
+		return desc
+	case state.EventResume:
+		return fmt.Sprintf("resumed %s in %s", shortID(ev.Detail), ev.WorkDir)
+	case state.EventStatus:
+		desc := ev.Previous + " → " + ev.Status
+		if ev.Previous == "" {
+			desc = "started " + ev.Status
+		}
+		if ev.Detail != "" {
+			desc += ": " + ev.Detail
+		}
+		return desc
+	case state.EventSend, state.EventAutoForward:
+		return quoteLine(ev.Detail)
+	case state.EventKill:
+		return "killed"
+	case state.EventEnd:
+		return "gone"
+	}
+	return ev.Detail
+}
+
+// quoteLine quotes the first line of text, shortened to fit a table.
+func quoteLine(text string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+	if r := []rune(line); len(r) > 60 {
+		line, more = string(r[:60]), true
+	}
+	if more {
+		line += "…"
+	}
+	return `"` + line + `"`
+}
+
+func shortID(uuid string) string {
+	if len(uuid) > 8 {
+		return uuid[:8]
+	}
+	return uuid
+}
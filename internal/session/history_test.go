@@ -0,0 +1,157 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+func TestSpans(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+	events := []state.Event{
+		{ID: 1, Name: "crab-a", Kind: state.EventNew, Time: at(0)},
+		{ID: 2, Name: "crab-a", Kind: state.EventStatus, Status: "running", Time: at(0)},
+		{ID: 3, Name: "crab-b", Kind: state.EventStatus, Status: "waiting", Time: at(1)},
+		{ID: 4, Name: "crab-a", Kind: state.EventSend, Time: at(2)}, // doesn't end a span
+		{ID: 5, Name: "crab-a", Kind: state.EventStatus, Status: "permission", Previous: "running", Time: at(10)},
+		{ID: 6, Name: "crab-a", Host: "bay9", Kind: state.EventStatus, Status: "running", Time: at(11)},
+		{ID: 7, Name: "crab-a", Kind: state.EventKill, Time: at(15)},
+	}
+	got := Spans(events, at(30))
+	want := []struct {
+		id     int64
+		status string
+		dur    time.Duration
+		open   bool
+	}{
+		{2, "running", 10 * time.Minute, false},
+		{3, "waiting", 29 * time.Minute, true},
+		{5, "permission", 5 * time.Minute, false},
+		{6, "running", 19 * time.Minute, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d spans, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		sp := got[i]
+		if sp.EventID != w.id || sp.Status != w.status || sp.Duration() != w.dur || sp.Open != w.open {
+			t.Errorf("span %d = %+v (%v), want %+v", i, sp, sp.Duration(), w)
+		}
+	}
+}
+
+func TestDescribeEvent(t *testing.T) {
+	tests := []struct {
+		ev   state.Event
+		want string
+	}{
+		{state.Event{Kind: state.EventNew, WorkDir: "/src/app", Detail: "fix the login\nand more"}, `created in /src/app: "fix the login…"`},
+		{state.Event{Kind: state.EventNew, WorkDir: "/src/app"}, "created in /src/app"},
+		{state.Event{Kind: state.EventResume, WorkDir: "/src/app", Detail: "0123456789abcdef"}, "resumed 01234567 in /src/app"},
+		{state.Event{Kind: state.EventStatus, Status: "waiting"}, "started waiting"},
+		{state.Event{Kind: state.EventStatus, Status: "permission", Previous: "running", Detail: "Bash(make test)"}, "running → permission: Bash(make test)"},
+		{state.Event{Kind: state.EventSend, Detail: "go on"}, `"go on"`},
+		{state.Event{Kind: state.EventKill}, "killed"},
+	}
+	for _, tt := range tests {
+		if got := DescribeEvent(tt.ev); got != tt.want {
+			t.Errorf("DescribeEvent(%+v) = %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestRecordListing(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	store, err := state.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	old := time.Now().Add(-48 * time.Hour)
+	now := time.Now()
+	a := Session{Name: "a", FullName: "crab-a", Status: Waiting, WorkDir: "/src/app"}
+	RecordListing(store, []Session{a}, nil, nil, old)
+	a.Status = Permission
+	a.Prompt = &PermissionPrompt{Tool: "Bash", Argument: "make"}
+	RecordListing(store, nil, nil, []Transition{{Session: a, Previous: Waiting}}, now)
+	RecordListing(store, nil, []Session{a}, nil, now)
+
+	events, err := store.ListEvents("crab-a", "", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if ev := events[1]; ev.Kind != state.EventStatus || ev.Previous != "waiting" || ev.Status != "permission" || ev.Detail != "Bash(make)" {
+		t.Errorf("transition recorded as %+v", ev)
+	}
+	if events[2].Kind != state.EventEnd {
+		t.Errorf("gone session recorded as %q", events[2].Kind)
+	}
+	if !events[0].Time.Equal(old.Truncate(time.Second)) {
+		t.Errorf("time = %v, want %v", events[0].Time, old.Truncate(time.Second))
+	}
+
+	if recent, _ := store.ListEvents("", "", now.Add(-time.Hour)); len(recent) != 2 {
+		t.Errorf("got %d events in the last hour, want 2", len(recent))
+	}
+	if n, err := store.PruneEvents(now.Add(-24 * time.Hour)); err != nil || n != 1 {
+		t.Errorf("PruneEvents = %d, %v; want 1", n, err)
+	}
+}
+
+func TestReconcileHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	store, err := state.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	before := time.Now().Add(-time.Hour)
+	now := time.Now()
+	running := func(name string) Session {
+		return Session{Name: name, FullName: "crab-" + name, Status: Running, WorkDir: "/src/" + name}
+	}
+	// Recorded before a restart: a and b running, c killed, d on another host
+	d := running("d")
+	d.Host = "bay3"
+	RecordListing(store, []Session{running("a"), running("b"), running("c"), d}, nil, nil, before)
+	_ = store.RecordEvent(state.Event{Name: "crab-c", Kind: state.EventKill, Time: before})
+
+	// Now a waits, b went, e is new and c's name is taken again
+	a, c, e := running("a"), running("c"), running("e")
+	a.Status = Waiting
+	if err := ReconcileHistory(store, "", []Session{a, c, e}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := store.ListEvents("", "", now.Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ev := range events {
+		got = append(got, ev.Name+" "+ev.Kind+" "+ev.Previous+">"+ev.Status)
+	}
+	want := []string{
+		"crab-a status running>waiting",
+		"crab-c status >running",
+		"crab-e status >running",
+		"crab-b end >",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("recorded %q, want %q", got, want)
+	}
+
+	// Reconciling the same listing again records nothing
+	_ = ReconcileHistory(store, "", []Session{a, c, e}, now)
+	if again, _ := store.ListEvents("", "", now.Add(-time.Second)); len(again) != len(events) {
+		t.Errorf("second reconcile recorded %d more events", len(again)-len(events))
+	}
+}
@@ -0,0 +1,123 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+// HookEvents are the Claude Code hook events crabctl listens to.
+var HookEvents = []string{"UserPromptSubmit", "PreToolUse", "PostToolUse", "Notification", "Stop"}
+
+// hookPayload is the JSON Claude Code passes to hook commands on stdin.
+type hookPayload struct {
+	SessionID string                 `json:"session_id"`
+	CWD       string                 `json:"cwd"`
+	Event     string                 `json:"hook_event_name"`
+	ToolName  string                 `json:"tool_name"`
+	ToolInput map[string]interface{} `json:"tool_input"`
+	Message   string                 `json:"message"`
+}
+
+// ParseHookPayload turns a Claude Code hook payload into an event for the
+// state DB. The caller fills in the tmux session name.
+func ParseHookPayload(data []byte) (state.HookEvent, error) {
+	var p hookPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return state.HookEvent{}, err
+	}
+
+	ev := state.HookEvent{
+		Event:     p.Event,
+		Tool:      p.ToolName,
+		SessionID: p.SessionID,
+		CWD:       p.CWD,
+	}
+	switch p.Event {
+	case "UserPromptSubmit":
+		ev.Status = Running.String()
+	case "PreToolUse", "PostToolUse":
+		ev.Status = Running.String()
+		ev.Detail = hookToolSummary(p.ToolName, p.ToolInput)
+	case "Notification":
+		// e.g. "Claude needs your permission to use Bash" or
+		// "Claude is waiting for your input"
+		if strings.Contains(strings.ToLower(p.Message), "permission") {
+			ev.Status = Permission.String()
+		} else {
+			ev.Status = Waiting.String()
+		}
+		ev.Detail = p.Message
+	case "Stop":
+		ev.Status = Waiting.String()
+	default:
+		return state.HookEvent{}, fmt.Errorf("unsupported hook event %q", p.Event)
+	}
+	return ev, nil
+}
+
+// hookToolSummary formats a tool call like the pane's ⏺ lines, e.g. "Bash(go test ./...)".
+func hookToolSummary(tool string, input map[string]interface{}) string {
+	if tool == "" {
+		return ""
+	}
+	for _, k := range []string{"command", "file_path", "path", "pattern", "url", "query", "description"} {
+		if v, ok := input[k].(string); ok && v != "" {
+			return tool + "(" + strings.Join(strings.Fields(v), " ") + ")"
+		}
+	}
+	return tool
+}
+
+// hookRunningGrace is how long a "running" hook event holds against a pane
+// that shows the idle prompt, which it may still do just after a prompt is
+// submitted. After that the pane wins: a turn interrupted with Esc ends
+// without a Stop hook, so the last "running" event is never replaced.
+const hookRunningGrace = 10 * time.Second
+
+// ApplyHookEvents overrides pane-scraped status with status pushed by Claude
+// Code hooks (see "crabctl hook"). Only local sessions are considered since
+// hooks write to the local state DB, and events older than the tmux session
+// are ignored as they belong to an earlier session with the same name.
+// Otherwise an event holds until the next one for the session replaces it.
+//
+// States only visible on screen (a permission or plan-approval menu, a TASK
+// DONE! marker) still win over the hook status when the pane shows them, as
+// does the idle prompt over a "running" event older than hookRunningGrace.
+func ApplyHookEvents(sessions []Session, events map[string]state.HookEvent, now time.Time) {
+	for i := range sessions {
+		s := &sessions[i]
+		if s.Host != "" {
+			continue
+		}
+		ev, ok := events[s.FullName]
+		if !ok {
+			continue
+		}
+		created := now.Add(-s.Duration).Truncate(time.Second)
+		if ev.UpdatedAt.Before(created) {
+			continue
+		}
+		st, err := ParseStatus(ev.Status)
+		if err != nil || st == Unknown {
+			continue
+		}
+		if st == Running && s.Status == Waiting && now.Sub(ev.UpdatedAt) > hookRunningGrace {
+			continue
+		}
+
+		if s.Status != Permission && s.Status != Confirm && s.Status != TaskDone {
+			s.Status = st
+		}
+		if ev.Tool != "" && ev.Detail != "" {
+			action := ev.Detail
+			if r := []rune(action); len(r) > 40 {
+				action = string(r[:37]) + "..."
+			}
+			s.LastAction = action
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+func TestParseHookPayload(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		wantStatus string
+		wantDetail string
+		wantErr    bool
+	}{
+		{
+			name:       "tool use",
+			payload:    `{"session_id":"abc","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"go test\n  ./..."}}`,
+			wantStatus: "running",
+			wantDetail: "Bash(go test ./...)",
+		},
+		{
+			name:       "permission notification",
+			payload:    `{"hook_event_name":"Notification","message":"Claude needs your permission to use Bash"}`,
+			wantStatus: "permission",
+			wantDetail: "Claude needs your permission to use Bash",
+		},
+		{
+			name:       "idle notification",
+			payload:    `{"hook_event_name":"Notification","message":"Claude is waiting for your input"}`,
+			wantStatus: "waiting",
+			wantDetail: "Claude is waiting for your input",
+		},
+		{
+			name:       "stop",
+			payload:    `{"hook_event_name":"Stop"}`,
+			wantStatus: "waiting",
+		},
+		{
+			name:    "unsupported event",
+			payload: `{"hook_event_name":"SessionStart"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			payload: `not json`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ParseHookPayload([]byte(tt.payload))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", ev)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ev.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", ev.Status, tt.wantStatus)
+			}
+			if ev.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", ev.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestApplyHookEvents(t *testing.T) {
+	now := time.Now()
+	sessions := []Session{
+		{FullName: "crab-hooked", Status: Unknown, Duration: time.Hour},
+		{FullName: "crab-stale", Status: Waiting, Duration: time.Minute},
+		{FullName: "crab-menu", Status: Permission, Duration: time.Hour},
+		{FullName: "crab-remote", Host: "bay3", Status: Waiting, Duration: time.Hour},
+		{FullName: "crab-old", Status: Waiting, Duration: time.Hour},
+		{FullName: "crab-long", Status: Unknown, Duration: time.Hour},
+		{FullName: "crab-submitted", Status: Waiting, Duration: time.Hour},
+		{FullName: "crab-idle", Status: Unknown, Duration: time.Hour},
+	}
+	events := map[string]state.HookEvent{
+		"crab-hooked": {Status: "running", Tool: "Bash", Detail: "Bash(make)", UpdatedAt: now},
+		"crab-stale":  {Status: "running", UpdatedAt: now.Add(-time.Hour)},
+		"crab-menu":   {Status: "running", UpdatedAt: now},
+		"crab-remote": {Status: "running", UpdatedAt: now},
+		// the pane shows the prompt long after the last tool call: the turn
+		// was interrupted
+		"crab-old": {Status: "running", Tool: "Bash", Detail: "Bash(make)", UpdatedAt: now.Add(-5 * time.Minute)},
+		// a long tool call the pane can't tell apart
+		"crab-long": {Status: "running", Tool: "Bash", Detail: "Bash(go test ./...)", UpdatedAt: now.Add(-5 * time.Minute)},
+		// the prompt was just submitted and the pane hasn't caught up
+		"crab-submitted": {Status: "running", UpdatedAt: now.Add(-2 * time.Second)},
+		"crab-idle":      {Status: "waiting", UpdatedAt: now.Add(-time.Hour / 2)},
+	}
+
+	ApplyHookEvents(sessions, events, now)
+
+	want := []Status{Running, Waiting, Permission, Waiting, Waiting, Running, Running, Waiting}
+	for i, s := range sessions {
+		if s.Status != want[i] {
+			t.Errorf("%s: status = %v, want %v", s.FullName, s.Status, want[i])
+		}
+	}
+	if sessions[0].LastAction != "Bash(make)" {
+		t.Errorf("last action = %q, want %q", sessions[0].LastAction, "Bash(make)")
+	}
+
+	long := []Session{{FullName: "crab-utf8", Status: Running, Duration: time.Hour}}
+	detail := "Bash(echo " + strings.Repeat("é", 40) + ")"
+	ApplyHookEvents(long, map[string]state.HookEvent{
+		"crab-utf8": {Status: "running", Tool: "Bash", Detail: detail, UpdatedAt: now},
+	}, now)
+	if got := long[0].LastAction; !utf8.ValidString(got) || utf8.RuneCountInString(got) != 40 {
+		t.Errorf("last action = %q, want 40 valid runes", got)
+	}
+}
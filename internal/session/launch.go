@@ -0,0 +1,204 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// defaultPermissionMode keeps crabs running unattended unless configured.
+const defaultPermissionMode = "bypass"
+
+// permissionModeArgs maps the permission modes accepted by crabctl to claude
+// flags. Every mode passes a flag so stored flags always say which was used.
+var permissionModeArgs = map[string][]string{
+	"default":     {"--permission-mode", "default"},
+	"plan":        {"--permission-mode", "plan"},
+	"acceptEdits": {"--permission-mode", "acceptEdits"},
+	"bypass":      {"--dangerously-skip-permissions"},
+}
+
+// sessionSelectionArgs are claude flags that pick which conversation to
+// open, with whether they take a value. They are dropped from stored flags
+// on resume.
+var sessionSelectionArgs = map[string]bool{
+	"--resume":       true,
+	"-r":             true,
+	"--session-id":   true,
+	"--continue":     false,
+	"-c":             false,
+	"--fork-session": false,
+}
+
+// ClaudeArgs returns the claude arguments for a launch config.
+func ClaudeArgs(c config.ClaudeConfig) ([]string, error) {
+	mode := c.PermissionMode
+	if mode == "" {
+		mode = defaultPermissionMode
+	}
+	modeArgs, ok := permissionModeArgs[mode]
+	if !ok {
+		return nil, fmt.Errorf("%w permission mode %q: use default, plan, acceptEdits or bypass", ErrInvalid, mode)
+	}
+	args := append([]string(nil), modeArgs...)
+	if c.Model != "" {
+		args = append(args, "--model", c.Model)
+	}
+	return append(args, c.Args...), nil
+}
+
+// LaunchArgs returns the claude binary ("" for the default) and arguments
+// for a new session on host: the config file's defaults for that host with
+// override applied on top.
+func LaunchArgs(host string, override config.ClaudeConfig) (string, []string, error) {
+	var cc config.ClaudeConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		cc = cfg.ClaudeFor(host)
+	}
+	cc = cc.Merge(override)
+	args, err := ClaudeArgs(cc)
+	return cc.Bin, args, err
+}
+
+// ResumeLaunchArgs returns the claude binary and arguments to resume a past
+// conversation: the flags its crab was started with when known, otherwise
+// the host's defaults, followed by --resume.
+func ResumeLaunchArgs(cs ClaudeSession) (string, []string, error) {
+	if cs.ClaudeFlags == "" {
+		bin, args, err := LaunchArgs(cs.Host, config.ClaudeConfig{Bin: cs.ClaudeBin})
+		return bin, append(args, "--resume", cs.UUID), err
+	}
+	return cs.ClaudeBin, ResumeArgs(cs.ClaudeFlags, cs.UUID), nil
+}
+
+// ResumeArgs turns stored flags (as in the CRABCTL_FLAGS tmux environment
+// variable) into arguments that resume conversation uuid.
+func ResumeArgs(flags, uuid string) []string {
+	stored := tmux.SplitArgs(flags)
+	var args []string
+	for i := 0; i < len(stored); i++ {
+		a := stored[i]
+		name, _, hasValue := strings.Cut(a, "=")
+		takesValue, ok := sessionSelectionArgs[name]
+		if !ok {
+			args = append(args, a)
+			continue
+		}
+		if takesValue && !hasValue && i+1 < len(stored) && !strings.HasPrefix(stored[i+1], "-") {
+			i++
+		}
+	}
+	return append(args, "--resume", uuid)
+}
+
+// SaveLaunchFlags copies the claude binary and flags from a running
+// session's tmux environment to the state DB, where they outlive the
+// session for resume.
+func SaveLaunchFlags(store *state.Store, ex tmux.Executor, fullName string) {
+	flags := ex.GetSessionEnv(fullName, tmux.FlagsEnv)
+	if flags == "" {
+		return
+	}
+	_ = store.SaveClaudeFlags(fullName, ex.HostName(), ex.GetSessionEnv(fullName, tmux.ClaudeBinEnv), flags)
+}
+
+// ExpandWorkDir resolves the directory for a new session on ex's host:
+// "~/" is expanded against that host's home, and an empty dir means the
+// current directory locally or the login directory on a remote host.
+func ExpandWorkDir(ex tmux.Executor, dir string) (string, error) {
+	if dir == "" {
+		if ex.HostName() == "" {
+			return os.Getwd()
+		}
+		return "", nil
+	}
+	if strings.HasPrefix(dir, "~/") {
+		home, err := ex.HomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, dir[2:]), nil
+	}
+	return dir, nil
+}
+
+// WaitForPrompt polls the pane until Claude shows the ❯ prompt.
+func WaitForPrompt(ex tmux.Executor, fullName string) error {
+	timeout := 30 * time.Second
+	poll := 500 * time.Millisecond
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(poll)
+		output, err := ex.CapturePaneOutput(fullName, 10)
+		if err != nil {
+			continue
+		}
+		status := DetectStatus(output)
+		if status == Waiting {
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out waiting for Claude prompt (%v)", timeout)
+}
+
+// SendMessage sends a message and verifies Claude started processing it.
+// Retries the Enter key if Claude is still waiting after sending.
+func SendMessage(ex tmux.Executor, fullName, message string) error {
+	if err := ex.SendKeys(fullName, message); err != nil {
+		return err
+	}
+
+	// Verify Claude started processing (transitioned away from Waiting)
+	for i := 0; i < 3; i++ {
+		time.Sleep(500 * time.Millisecond)
+		output, err := ex.CapturePaneOutput(fullName, 10)
+		if err != nil {
+			continue
+		}
+		status := DetectStatus(output)
+		if status != Waiting {
+			return nil // Claude is processing
+		}
+		// Still waiting — the Enter key might have been lost, resend just Enter
+		_ = ex.SendKey(fullName, "Enter")
+	}
+	return nil // sent text, best effort
+}
+
+// Kill kills a session, first recording in store (if not nil) what is
+// needed to resume it: its Claude session UUID, directory, first message
+// and launch flags. Its queued messages are dropped and the kill goes in
+// the history.
+func Kill(ex tmux.Executor, store *state.Store, fullName string) error {
+	host := ex.HostName()
+	workDir := ex.GetPanePath(fullName)
+	paneContent, _ := ex.CapturePaneOutput(fullName, 50)
+	var created time.Time
+	if host == "" {
+		created = tmux.GetSessionCreated(fullName)
+	}
+	uuid, firstMsg := FindSessionUUID(ex, workDir, created, paneContent, nil)
+	if store != nil && uuid != "" {
+		SaveLaunchFlags(store, ex, fullName)
+	}
+
+	if err := ex.KillSession(fullName); err != nil {
+		return err
+	}
+
+	if store != nil {
+		if uuid != "" {
+			_ = store.MarkKilled(fullName, host, uuid, workDir, firstMsg)
+		}
+		_ = store.ClearQueue(fullName, host)
+		_ = store.RecordEvent(state.Event{Name: fullName, Host: host, Kind: state.EventKill, WorkDir: workDir})
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestClaudeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ClaudeConfig
+		want []string
+		err  bool
+	}{
+		{"defaults to bypass", config.ClaudeConfig{}, []string{"--dangerously-skip-permissions"}, false},
+		{"plan with model", config.ClaudeConfig{PermissionMode: "plan", Model: "opus"},
+			[]string{"--permission-mode", "plan", "--model", "opus"}, false},
+		{"extra args", config.ClaudeConfig{PermissionMode: "default", Args: []string{"--verbose"}},
+			[]string{"--permission-mode", "default", "--verbose"}, false},
+		{"invalid mode", config.ClaudeConfig{PermissionMode: "yolo"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClaudeArgs(tt.cfg)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeArgs(t *testing.T) {
+	flags := tmux.QuoteArgs([]string{
+		"--permission-mode", "plan", "--resume", "old-uuid",
+		"--append-system-prompt", "be brief, it's late", "-c",
+	})
+	got := ResumeArgs(flags, "new-uuid")
+	want := []string{"--permission-mode", "plan", "--append-system-prompt", "be brief, it's late", "--resume", "new-uuid"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Flags stored before quoting was added are plain space-joined
+	got = ResumeArgs("--dangerously-skip-permissions --resume=abc", "new-uuid")
+	want = []string{"--dangerously-skip-permissions", "--resume", "new-uuid"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// textOptionDelay is how long to wait after picking a free-text option
+// before typing, so Claude has switched the menu to a text field.
+const textOptionDelay = 300 * time.Millisecond
+
+// Menu is a numbered menu near the bottom of the pane, e.g. plan approval:
+//
+//	Claude has written up a plan.
+//
+//	❯ 1. Yes, clear context and bypass permissions
+//	  2. Yes, and bypass permissions
+//	  3. Yes, manually approve edits
+//	  4. Type here to tell Claude what to change
+type Menu struct {
+	Question string // line above the options, may be empty
+	Options  []MenuOption
+}
+
+// ParseMenu parses the numbered menu near the bottom of the pane.
+// Returns nil when the pane doesn't end with one.
+func ParseMenu(output string) *Menu {
+	lines := menuLines(output)
+	options, above := parseNumberedMenu(lines)
+	if len(options) == 0 {
+		return nil
+	}
+	m := &Menu{Options: options}
+	for i := above; i >= 0; i-- {
+		if l := lines[i]; l != "" && !strings.HasPrefix(l, "╌") && !strings.HasPrefix(l, "───") {
+			m.Question = l
+			break
+		}
+	}
+	return m
+}
+
+// TextOption returns the option that lets the user type an answer, e.g.
+// "Type here to tell Claude what to change", or nil if there is none.
+func (m *Menu) TextOption() *MenuOption {
+	for i, o := range m.Options {
+		label := strings.ToLower(o.Label)
+		if strings.Contains(label, "type here") || strings.Contains(label, "tell claude") {
+			return &m.Options[i]
+		}
+	}
+	return nil
+}
+
+// AnswerMenu answers the menu shown in a session: answer is an option
+// number, or any other text to pick the menu's free-text option and type it.
+// Returns the option picked.
+func AnswerMenu(ex tmux.Executor, fullName, answer string) (MenuOption, error) {
+	output, err := ex.CapturePaneOutput(fullName, 50)
+	if err != nil {
+		return MenuOption{}, err
+	}
+	m := ParseMenu(output)
+	if m == nil {
+		return MenuOption{}, fmt.Errorf("no menu on screen")
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(answer)); err == nil {
+		if n < 1 || n > len(m.Options) {
+			return MenuOption{}, fmt.Errorf("no option %d (menu has %d)", n, len(m.Options))
+		}
+		return m.Options[n-1], ex.SendKey(fullName, strconv.Itoa(n))
+	}
+
+	opt := m.TextOption()
+	if opt == nil {
+		return MenuOption{}, fmt.Errorf("menu has no option to type an answer")
+	}
+	if err := ex.SendKey(fullName, strconv.Itoa(opt.Number)); err != nil {
+		return *opt, err
+	}
+	time.Sleep(textOptionDelay)
+	return *opt, ex.SendKeys(fullName, answer)
+}
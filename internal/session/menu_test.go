@@ -0,0 +1,37 @@
+package session
+
+import "testing"
+
+func TestParseMenu(t *testing.T) {
+	m := ParseMenu(`╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌
+ Claude has written up a plan.
+
+ ❯ 1. Yes, clear context and bypass permissions
+   2. Yes, and bypass permissions
+   3. Yes, manually approve edits
+   4. Type here to tell Claude what to change
+
+ ctrl-g to edit in Nvim · ~/.claude/plans/foo.md`)
+	if m == nil {
+		t.Fatal("expected a menu")
+	}
+	if m.Question != "Claude has written up a plan." {
+		t.Errorf("question = %q", m.Question)
+	}
+	if len(m.Options) != 4 {
+		t.Fatalf("got %d options, want 4", len(m.Options))
+	}
+	if !m.Options[0].Selected || m.Options[1].Selected {
+		t.Errorf("selection not parsed: %+v", m.Options)
+	}
+	if m.Options[2].Label != "Yes, manually approve edits" {
+		t.Errorf("option 3 label = %q", m.Options[2].Label)
+	}
+	if opt := m.TextOption(); opt == nil || opt.Number != 4 {
+		t.Errorf("text option = %+v, want 4", opt)
+	}
+
+	if m := ParseMenu("⏺ Done.\n\n❯\n───\n  ? for shortcuts"); m != nil {
+		t.Errorf("expected no menu at the input prompt, got %+v", m)
+	}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// usageRefreshInterval is how often a session's token usage is re-read
+// while its session file keeps changing.
+const usageRefreshInterval = 30 * time.Second
+
+// MergeState carries forward state from known sessions into freshly
+// listed ones and resolves UUIDs of new sessions by reading their session
+// files through ex (the executor the sessions were listed from).
+func MergeState(ex tmux.Executor, store *state.Store, pricing Pricing, known, sessions []Session) {
+	// Build lookup from existing sessions
+	byName := make(map[string]Session)
+	for _, s := range known {
+		byName[s.FullName] = s
+	}
+
+	// Collect all claimed UUIDs so new resolutions skip already-matched files
+	claimed := make(map[string]bool)
+	for _, s := range known {
+		if s.SessionUUID != "" {
+			claimed[s.SessionUUID] = true
+		}
+	}
+
+	for i := range sessions {
+		s := &sessions[i]
+		if old, ok := byName[s.FullName]; ok && old.Host == s.Host {
+			// Carry forward stable project directory from first discovery.
+			// Pane's current path can change if Claude cd's.
+			if old.WorkDir != "" {
+				s.WorkDir = old.WorkDir
+			}
+			// Carry forward already-resolved UUID
+			if old.SessionUUID != "" {
+				s.SessionUUID = old.SessionUUID
+				s.SessionFirstMsg = old.SessionFirstMsg
+			}
+			// Carry forward PRURL if the PR number hasn't changed.
+			if old.PRURL != "" && old.PR == s.PR {
+				s.PRURL = old.PRURL
+			}
+			s.Usage = old.Usage
+			s.UsageReadAt = old.UsageReadAt
+			s.Branch = old.Branch
+			s.RepoDir = old.RepoDir
+		} else if store != nil {
+			// Worktree created by `crabctl new --worktree`, unless the
+			// record is left over from an earlier session of that name
+			if wt, ok := store.GetWorktree(s.FullName, s.Host); ok && strings.HasPrefix(s.WorkDir, wt.Path) {
+				s.Branch = wt.Branch
+				s.RepoDir = wt.RepoDir
+			}
+		}
+
+		// Resolve the repo of new sessions, which the history records
+		if s.RepoDir == "" && s.WorkDir != "" {
+			s.RepoDir = RepoRoot(ex, s.WorkDir)
+		}
+
+		// Resolve UUID for new sessions
+		if s.SessionUUID == "" && s.WorkDir != "" {
+			s.SessionUUID, s.SessionFirstMsg = FindSessionUUID(
+				ex, s.WorkDir, time.Now().Add(-s.Duration), s.PaneContent, claimed,
+			)
+			if s.SessionUUID != "" {
+				claimed[s.SessionUUID] = true
+				// Persist to DB so the UUID survives accidental kills
+				if store != nil {
+					store.SaveSessionUUID(s.FullName, s.Host, s.SessionUUID, s.WorkDir, s.SessionFirstMsg)
+					SaveLaunchFlags(store, ex, s.FullName)
+				}
+			}
+		}
+
+		// Compute LastActive from the known session file (single stat call)
+		if s.SessionUUID != "" {
+			s.LastActive = SessionFileModTime(ex, s.WorkDir, s.SessionUUID)
+		}
+
+		// Re-read token usage when the session file changed since the last
+		// read, at most every usageRefreshInterval as the whole file is parsed
+		if s.LastActive.After(s.UsageReadAt) && time.Since(s.UsageReadAt) >= usageRefreshInterval {
+			s.Usage = SessionUsage(ex, s.WorkDir, s.SessionUUID, pricing)
+			s.UsageReadAt = time.Now()
+		}
+
+		s.PaneContent = "" // no longer needed after UUID resolution
+	}
+}
@@ -0,0 +1,234 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// notifyTimeout bounds how long a notification command may run.
+const notifyTimeout = 10 * time.Second
+
+// defaultNotifyOn are the statuses that notify unless configured otherwise.
+var defaultNotifyOn = []Status{Permission, Confirm, TaskDone}
+
+// Transition is a session whose status changed between two listings.
+type Transition struct {
+	Session  Session
+	Previous Status
+}
+
+// Transitions returns the sessions whose status differs from the same
+// session in known. Sessions that weren't known yet are left out, so the
+// first listing after startup reports nothing.
+func Transitions(known, sessions []Session) []Transition {
+	prev := make(map[string]Session, len(known))
+	for _, s := range known {
+		prev[s.Host+":"+s.FullName] = s
+	}
+	var out []Transition
+	for _, s := range sessions {
+		old, ok := prev[s.Host+":"+s.FullName]
+		if ok && old.Status != s.Status {
+			out = append(out, Transition{Session: s, Previous: old.Status})
+		}
+	}
+	return out
+}
+
+// Diff returns the sessions that are in sessions but not in known, and
+// those that were in known but are gone from sessions. Both listings must
+// be complete listings of the same hosts.
+func Diff(known, sessions []Session) (appeared, gone []Session) {
+	now := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		now[s.Host+":"+s.FullName] = true
+	}
+	before := make(map[string]bool, len(known))
+	for _, s := range known {
+		before[s.Host+":"+s.FullName] = true
+		if !now[s.Host+":"+s.FullName] {
+			gone = append(gone, s)
+		}
+	}
+	for _, s := range sessions {
+		if !before[s.Host+":"+s.FullName] {
+			appeared = append(appeared, s)
+		}
+	}
+	return appeared, gone
+}
+
+// Message describes the transition for a person, e.g.
+// "bay9:fix-tests needs permission: Bash(go test ./...)".
+func (t Transition) Message() string {
+	s := t.Session
+	label := s.Label()
+	switch s.Status {
+	case Permission:
+		if s.Prompt != nil {
+			return label + " needs permission: " + s.Prompt.Summary()
+		}
+		return label + " needs permission"
+	case Confirm:
+		return label + " is waiting for confirmation"
+	case TaskDone:
+		return label + " is done"
+	case Waiting:
+		return label + " is waiting for input"
+	}
+	return label + " is " + s.Status.String()
+}
+
+// NotifyEvent is what notification commands get: the session, its previous
+// status and the message shown by the other notifiers.
+type NotifyEvent struct {
+	Entry
+	Previous string `json:"previous"`
+	Message  string `json:"message"`
+	Prompt   string `json:"prompt,omitempty"` // pending permission prompt
+}
+
+// NewNotifyEvent returns the event for t.
+func NewNotifyEvent(t Transition) NotifyEvent {
+	e := NotifyEvent{Entry: NewEntry(t.Session), Previous: t.Previous.String(), Message: t.Message()}
+	if t.Session.Prompt != nil {
+		e.Prompt = t.Session.Prompt.Summary()
+	}
+	return e
+}
+
+// Notifier fires the notifiers configured in the notify section of the
+// config when a session changes to a status worth telling the user about.
+type Notifier struct {
+	cfg     config.NotifyConfig
+	on      map[Status]bool
+	command []*template.Template
+}
+
+// NewNotifier validates cfg and returns a notifier for it.
+func NewNotifier(cfg config.NotifyConfig) (*Notifier, error) {
+	n := &Notifier{cfg: cfg, on: make(map[Status]bool)}
+	for _, name := range cfg.On {
+		st, err := ParseStatus(name)
+		if err != nil {
+			return nil, fmt.Errorf("notify.on: %w", err)
+		}
+		n.on[st] = true
+	}
+	if len(cfg.On) == 0 {
+		for _, st := range defaultNotifyOn {
+			n.on[st] = true
+		}
+	}
+	for i, arg := range cfg.Command {
+		t, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("notify.command: %w", err)
+		}
+		n.command = append(n.command, t)
+	}
+	return n, nil
+}
+
+// LoadNotifier returns the notifier configured in the config file. A broken
+// notify section is reported along with a notifier that does nothing.
+func LoadNotifier() (*Notifier, error) {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		return &Notifier{}, err
+	}
+	n, err := NewNotifier(cfg.Notify)
+	if err != nil {
+		return &Notifier{}, err
+	}
+	return n, nil
+}
+
+// Enabled reports whether any notifier is configured.
+func (n *Notifier) Enabled() bool {
+	return len(n.cfg.Command) > 0 || len(n.cfg.JSONCommand) > 0 || n.cfg.Bell || n.cfg.Tmux
+}
+
+// Wants reports whether t should be notified: a notifier is configured and
+// the session changed to one of the notify statuses.
+func (n *Notifier) Wants(t Transition) bool {
+	return n.Enabled() && n.on[t.Session.Status]
+}
+
+// Notify fires every configured notifier for t and returns their errors.
+// Commands run on this machine, whichever host the session is on.
+func (n *Notifier) Notify(t Transition) error {
+	ev := NewNotifyEvent(t)
+	var errs []error
+	if n.cfg.Bell {
+		if err := ringBell(); err != nil {
+			errs = append(errs, fmt.Errorf("bell: %w", err))
+		}
+	}
+	if n.cfg.Tmux {
+		if err := tmux.DisplayMessage("crabctl: " + ev.Message); err != nil {
+			errs = append(errs, fmt.Errorf("tmux: %w", err))
+		}
+	}
+	if len(n.command) > 0 {
+		args := make([]string, len(n.command))
+		for i, t := range n.command {
+			var b strings.Builder
+			if err := t.Execute(&b, ev); err != nil {
+				return errors.Join(append(errs, fmt.Errorf("notify.command: %w", err))...)
+			}
+			args[i] = b.String()
+		}
+		if err := runNotifyCommand(args, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", args[0], err))
+		}
+	}
+	if len(n.cfg.JSONCommand) > 0 {
+		data, _ := json.Marshal(ev)
+		if err := runNotifyCommand(n.cfg.JSONCommand, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.cfg.JSONCommand[0], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ringBell writes BEL to the controlling terminal. Processes without one,
+// like a detached daemon, have no bell to ring.
+func ringBell() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil
+	}
+	defer tty.Close()
+	_, err = tty.Write([]byte("\a"))
+	return err
+}
+
+func runNotifyCommand(args []string, stdin []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
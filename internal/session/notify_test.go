@@ -0,0 +1,83 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/simon/crabctl/internal/config"
+)
+
+func TestTransitions(t *testing.T) {
+	known := []Session{
+		{Name: "a", FullName: "crab-a", Status: Running},
+		{Name: "b", FullName: "crab-b", Status: Waiting},
+		{Name: "a", FullName: "crab-a", Host: "bay9", Status: Running},
+	}
+	sessions := []Session{
+		{Name: "a", FullName: "crab-a", Status: Permission},
+		{Name: "b", FullName: "crab-b", Status: Waiting},
+		{Name: "a", FullName: "crab-a", Host: "bay9", Status: TaskDone},
+		{Name: "c", FullName: "crab-c", Status: Confirm}, // new, not a transition
+	}
+	got := Transitions(known, sessions)
+	if len(got) != 2 {
+		t.Fatalf("got %d transitions, want 2: %+v", len(got), got)
+	}
+	if got[0].Session.Host != "" || got[0].Previous != Running || got[0].Session.Status != Permission {
+		t.Errorf("first transition = %+v", got[0])
+	}
+	if msg := got[1].Message(); msg != "bay9:a is done" {
+		t.Errorf("Message() = %q", msg)
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	if _, err := NewNotifier(config.NotifyConfig{On: []string{"sleeping"}}); err == nil {
+		t.Error("unknown status in notify.on accepted")
+	}
+	if _, err := NewNotifier(config.NotifyConfig{Command: []string{"echo", "{{.Name"}}); err == nil {
+		t.Error("broken command template accepted")
+	}
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	jsonFile := filepath.Join(dir, "event.json")
+	n, err := NewNotifier(config.NotifyConfig{
+		Command:     []string{"sh", "-c", `printf '%s' "$1" > ` + argsFile, "sh", "{{.Name}} {{.Previous}} -> {{.Status}}"},
+		JSONCommand: []string{"sh", "-c", "cat > " + jsonFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := Transition{
+		Session:  Session{Name: "fix", FullName: "crab-fix", Status: Permission, Prompt: &PermissionPrompt{Tool: "Bash", Argument: "make"}},
+		Previous: Running,
+	}
+	if !n.Wants(tr) {
+		t.Error("permission should notify by default")
+	}
+	if n.Wants(Transition{Session: Session{Status: Running}, Previous: Waiting}) {
+		t.Error("running should not notify by default")
+	}
+	if err := n.Notify(tr); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if string(args) != "fix running -> permission" {
+		t.Errorf("command got %q", args)
+	}
+	data, _ := os.ReadFile(jsonFile)
+	var ev NotifyEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatalf("json_command got %q: %v", data, err)
+	}
+	if ev.Name != "fix" || ev.Host != "local" || ev.Previous != "running" || ev.Prompt != "Bash(make)" ||
+		!strings.Contains(ev.Message, "needs permission") {
+		t.Errorf("event = %+v", ev)
+	}
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MenuOption is a numbered option of a menu shown in the pane.
+type MenuOption struct {
+	Number   int
+	Label    string
+	Selected bool // highlighted by the ❯ cursor
+}
+
+// PermissionPrompt is a tool permission request parsed from the pane, e.g.
+//
+//	Bash command
+//
+//	  go test ./...
+//	  Run the tests
+//
+//	Do you want to proceed?
+//	❯ 1. Yes
+//	  2. Yes, and don't ask again for go test commands in /src/foo
+//	  3. No, and tell Claude what to do differently (esc)
+type PermissionPrompt struct {
+	Tool        string // e.g. "Bash", "Edit", "mcp__github__create_issue"
+	Argument    string // e.g. the command or file path, may be empty; one line per line of the dialog body
+	Description string // what a Bash command is for, e.g. "Run the tests"
+	Question    string // e.g. "Do you want to proceed?"
+	Options     []MenuOption
+}
+
+// PermissionAnswer is a way to answer a permission prompt.
+type PermissionAnswer int
+
+const (
+	Approve PermissionAnswer = iota // allow this call once
+	Always                          // allow and don't ask again
+	Deny                            // refuse and let the user tell Claude what to do
+)
+
+// Summary returns the request in the pane's ⏺ style, e.g. "Bash(go test ./...)".
+// Only the first line of the argument is shown, cut to fit on a line.
+func (p *PermissionPrompt) Summary() string {
+	tool := p.Tool
+	if tool == "" {
+		tool = "unknown tool"
+	}
+	if p.Argument == "" {
+		return tool
+	}
+	arg, _, more := strings.Cut(p.Argument, "\n")
+	if r := []rune(arg); len(r) > 80 {
+		arg = string(r[:77]) + "..."
+	}
+	if more {
+		arg += " …"
+	}
+	return tool + "(" + arg + ")"
+}
+
+// Key returns the key that picks the option for an answer, or "" when the
+// menu has no such option (e.g. no "don't ask again" for some tools).
+func (p *PermissionPrompt) Key(a PermissionAnswer) string {
+	for i, o := range p.Options {
+		label := strings.ToLower(o.Label)
+		var ok bool
+		switch a {
+		case Approve:
+			ok = i == 0 && strings.HasPrefix(label, "yes")
+		case Always:
+			ok = i > 0 && strings.HasPrefix(label, "yes")
+		case Deny:
+			ok = strings.HasPrefix(label, "no")
+		}
+		if ok {
+			return strconv.Itoa(o.Number)
+		}
+	}
+	return ""
+}
+
+// permissionHeaders maps the heading of a permission dialog to its tool.
+var permissionHeaders = map[string]string{
+	"bash command": "Bash",
+	"edit file":    "Edit",
+	"create file":  "Write",
+	"write file":   "Write",
+	"read file":    "Read",
+	"fetch":        "WebFetch",
+	"web search":   "WebSearch",
+	"tool use":     "",
+}
+
+// toolCallRe matches a tool call line like "mcp__gh__get_issue(number: 1)".
+var toolCallRe = regexp.MustCompile(`^(?:⏺\s*)?([A-Za-z][\w.:-]*)\((.*)\)`)
+
+// fileQuestionRe extracts the file from "Do you want to make this edit to foo.go?".
+var fileQuestionRe = regexp.MustCompile(`(?i)(?:edit to|create|write to|overwrite) (.+?)\?`)
+
+// ParsePermissionPrompt parses a permission dialog near the bottom of the
+// pane. Returns nil when the pane doesn't end with one.
+func ParsePermissionPrompt(output string) *PermissionPrompt {
+	lines := menuLines(output)
+	options, above := parseNumberedMenu(lines)
+	if len(options) == 0 {
+		return nil
+	}
+
+	// The question sits right above the options
+	i := above
+	for i >= 0 && lines[i] == "" {
+		i--
+	}
+	if i < 0 || !isPermissionQuestion(lines[i]) {
+		return nil
+	}
+	p := &PermissionPrompt{Question: lines[i], Options: options}
+
+	// Walk up to the dialog heading, collecting the body in between
+	var body []string
+	header := -1
+	for j := i - 1; j >= 0 && i-j <= 20; j-- {
+		l := lines[j]
+		if tool, ok := permissionHeaders[strings.ToLower(l)]; ok {
+			p.Tool = tool
+			header = j
+			break
+		}
+		if strings.HasPrefix(l, "───") {
+			break
+		}
+		if l != "" && !strings.HasPrefix(l, "╭") && !strings.HasPrefix(l, "╰") {
+			body = append([]string{l}, body...)
+		}
+	}
+
+	// Keep every line of the argument: a long command wraps, and matching
+	// a policy rule against only the first line would miss what follows.
+	// Claude Code always shows a description below a Bash command ("Run
+	// shell command" when Claude gave none), so its last line is that.
+	if header >= 0 && p.Tool != "" && len(body) > 0 {
+		if p.Tool == "Bash" && len(body) > 1 {
+			p.Description = body[len(body)-1]
+			body = body[:len(body)-1]
+		}
+		p.Argument = strings.Join(body, "\n")
+	}
+	if p.Tool == "Edit" || p.Tool == "Write" {
+		if m := fileQuestionRe.FindStringSubmatch(p.Question); m != nil {
+			p.Argument = m[1]
+		}
+	}
+	if p.Tool == "" {
+		for _, l := range body {
+			if m := toolCallRe.FindStringSubmatch(l); m != nil {
+				p.Tool, p.Argument = m[1], m[2]
+				break
+			}
+		}
+	}
+	return p
+}
+
+func isPermissionQuestion(line string) bool {
+	return strings.HasPrefix(strings.ToLower(line), "do you want to")
+}
+
+// menuLines splits pane output into trimmed lines with dialog box borders
+// (│ ... │) removed, so boxed and unboxed dialogs parse the same.
+func menuLines(output string) []string {
+	lines := strings.Split(output, "\n")
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "│")
+		l = strings.TrimSuffix(l, "│")
+		lines[i] = strings.TrimSpace(l)
+	}
+	return lines
+}
+
+// parseNumberedMenu finds the numbered menu closest to the bottom of the
+// (menuLines-trimmed) pane. Returns its options in order and the index of
+// the line above the menu; no options when there is no menu.
+func parseNumberedMenu(lines []string) ([]MenuOption, int) {
+	// Skip hints below the menu, e.g. "Esc to cancel · Tab to amend"
+	i := len(lines) - 1
+	for skipped := 0; i >= 0; i-- {
+		if isNumberedMenuItem(lines[i]) {
+			break
+		}
+		// An input prompt below means Claude isn't showing a menu
+		if strings.HasPrefix(lines[i], "❯") || lines[i] == ">" {
+			return nil, -1
+		}
+		if lines[i] != "" {
+			if skipped++; skipped > 4 {
+				return nil, -1
+			}
+		}
+	}
+	if i < 0 {
+		return nil, -1
+	}
+
+	var options []MenuOption
+	var cont []string // wrapped label lines below the item above them
+	for ; i >= 0; i-- {
+		l := lines[i]
+		if !isNumberedMenuItem(l) {
+			// A wrapped label continues below its numbered item
+			if l != "" && i > 0 && isNumberedMenuItem(lines[i-1]) {
+				cont = append([]string{l}, cont...)
+				continue
+			}
+			break
+		}
+		o := parseMenuItem(l)
+		if len(cont) > 0 {
+			o.Label += " " + strings.Join(cont, " ")
+			cont = nil
+		}
+		options = append(options, o)
+	}
+
+	sort.Slice(options, func(a, b int) bool { return options[a].Number < options[b].Number })
+	for k, o := range options {
+		if o.Number != k+1 {
+			return nil, -1
+		}
+	}
+	return options, i
+}
+
+// parseMenuItem parses "❯ 1. Yes" into an option.
+func parseMenuItem(line string) MenuOption {
+	s := strings.TrimSpace(line)
+	selected := strings.HasPrefix(s, "❯")
+	s = strings.TrimSpace(strings.TrimPrefix(s, "❯"))
+	dot := strings.IndexByte(s, '.')
+	n, _ := strconv.Atoi(s[:dot])
+	return MenuOption{Number: n, Label: strings.TrimSpace(s[dot+1:]), Selected: selected}
+}
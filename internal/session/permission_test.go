@@ -0,0 +1,150 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePermissionPrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tool     string
+		arg      string
+		desc     string
+		approve  string
+		always   string
+		deny     string
+		noPrompt bool
+	}{
+		{
+			name: "bash command",
+			input: `⏺ Bash(go test ./...)
+────────────────────────────────────────
+ Bash command
+
+   go test ./...
+   Run the tests
+
+ Do you want to proceed?
+ ❯ 1. Yes
+   2. Yes, and don't ask again for go test commands in
+   /src/foo
+   3. No, and tell Claude what to do differently (esc)
+
+ Esc to cancel`,
+			tool: "Bash", arg: "go test ./...", desc: "Run the tests",
+			approve: "1", always: "2", deny: "3",
+		},
+		{
+			name: "wrapped bash command",
+			input: ` Bash command
+
+   go test ./internal/session/... ./internal/tmux/... ./internal/tui/... -run X
+   && rm -rf ~
+   Run the tests
+
+ Do you want to proceed?
+ ❯ 1. Yes
+   2. No, and tell Claude what to do differently (esc)`,
+			tool: "Bash", arg: "go test ./internal/session/... ./internal/tmux/... ./internal/tui/... -run X\n&& rm -rf ~", desc: "Run the tests",
+			approve: "1", deny: "2",
+		},
+		{
+			name: "boxed edit without always option",
+			input: `╭──────────────────────────────────────╮
+│ Edit file                            │
+│ ╭──────────────────────────────────╮ │
+│ │ main.go                          │ │
+│ ╰──────────────────────────────────╯ │
+│ Do you want to make this edit to main.go? │
+│ ❯ 1. Yes                             │
+│   2. No, and tell Claude what to do differently (esc) │
+╰──────────────────────────────────────╯`,
+			tool: "Edit", arg: "main.go",
+			approve: "1", always: "", deny: "2",
+		},
+		{
+			name: "mcp tool use",
+			input: ` Tool use
+
+   github - create_issue(title: "x") (MCP)
+   mcp__github__create_issue(title: "x")
+
+ Do you want to proceed?
+ ❯ 1. Yes
+   2. No, and tell Claude what to do differently (esc)`,
+			tool: "mcp__github__create_issue", arg: `title: "x"`,
+			approve: "1", deny: "2",
+		},
+		{
+			name: "plan approval is not a permission prompt",
+			input: ` Claude has written up a plan.
+
+ ❯ 1. Yes, clear context and bypass permissions
+   2. Yes, and bypass permissions
+   3. Type here to tell Claude what to change`,
+			noPrompt: true,
+		},
+		{
+			name: "numbered list above the input prompt",
+			input: `⏺ Do you want to proceed? Options:
+  1. Yes
+  2. No
+
+❯
+───────────────────
+  ? for shortcuts`,
+			noPrompt: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ParsePermissionPrompt(tt.input)
+			if tt.noPrompt {
+				if p != nil {
+					t.Fatalf("expected no prompt, got %+v", p)
+				}
+				return
+			}
+			if p == nil {
+				t.Fatal("expected a prompt")
+			}
+			if p.Tool != tt.tool || p.Argument != tt.arg {
+				t.Errorf("got %s(%s), want %s(%s)", p.Tool, p.Argument, tt.tool, tt.arg)
+			}
+			if p.Description != tt.desc {
+				t.Errorf("description = %q, want %q", p.Description, tt.desc)
+			}
+			if k := p.Key(Approve); k != tt.approve {
+				t.Errorf("approve key = %q, want %q", k, tt.approve)
+			}
+			if k := p.Key(Always); k != tt.always {
+				t.Errorf("always key = %q, want %q", k, tt.always)
+			}
+			if k := p.Key(Deny); k != tt.deny {
+				t.Errorf("deny key = %q, want %q", k, tt.deny)
+			}
+		})
+	}
+}
+
+func TestPermissionPromptSummary(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"", "Bash"},
+		{"go test ./...", "Bash(go test ./...)"},
+		{"go test ./...\n-run TestX", "Bash(go test ./... …)"},
+		{strings.Repeat("x", 90), "Bash(" + strings.Repeat("x", 77) + "...)"},
+		{strings.Repeat("é", 90), "Bash(" + strings.Repeat("é", 77) + "...)"},
+	}
+	for _, tt := range tests {
+		p := &PermissionPrompt{Tool: "Bash", Argument: tt.arg}
+		if got := p.Summary(); got != tt.want {
+			t.Errorf("Summary(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/simon/crabctl/internal/config"
+)
+
+// PolicyAction is what a policy decides to do with a permission prompt.
+type PolicyAction string
+
+const (
+	PolicyNone  PolicyAction = ""      // no rule matched; leave it to the user
+	PolicyAllow PolicyAction = "allow" // approve once
+	PolicyDeny  PolicyAction = "deny"  // refuse
+)
+
+// shellOperators are the shell constructs that can chain a second command
+// onto an allowed one, e.g. "go test ./... && rm -rf ~".
+var shellOperators = []string{";", "&&", "||", "|", "`", "$(", ">", "<", "\n"}
+
+// PolicyRule is a parsed rule such as "Bash(go test*)".
+type PolicyRule struct {
+	Text string // rule as written in the policy file
+	tool *regexp.Regexp
+	arg  *regexp.Regexp // nil matches any argument
+}
+
+// ParsePolicyRule parses "Tool" or "Tool(pattern)". Both parts may use *
+// to match any run of characters.
+func ParsePolicyRule(text string) (PolicyRule, error) {
+	r := PolicyRule{Text: text}
+	text = strings.TrimSpace(text)
+	tool, arg, hasArg := strings.Cut(text, "(")
+	if hasArg {
+		if !strings.HasSuffix(arg, ")") {
+			return r, fmt.Errorf("rule %q: missing closing parenthesis", r.Text)
+		}
+		arg = strings.TrimSuffix(arg, ")")
+	}
+	tool = strings.TrimSpace(tool)
+	if tool == "" {
+		return r, fmt.Errorf("rule %q: missing tool name", r.Text)
+	}
+	r.tool = globRegexp(tool)
+	if hasArg && arg != "*" {
+		r.arg = globRegexp(arg)
+	}
+	return r, nil
+}
+
+// globRegexp compiles a pattern where * matches anything (including /).
+func globRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// Matches reports whether the rule covers the prompt's tool and argument.
+func (r PolicyRule) Matches(p *PermissionPrompt) bool {
+	if p.Tool == "" || !r.tool.MatchString(p.Tool) {
+		return false
+	}
+	return r.arg == nil || r.arg.MatchString(p.Argument)
+}
+
+// Policy decides permission prompts from allow and deny rules. Deny rules
+// win over allow rules.
+type Policy struct {
+	Allow []PolicyRule
+	Deny  []PolicyRule
+}
+
+// NewPolicy parses the rules of a policy file.
+func NewPolicy(pc config.PolicyConfig) (*Policy, error) {
+	p := &Policy{}
+	for _, text := range pc.Allow {
+		r, err := ParsePolicyRule(text)
+		if err != nil {
+			return nil, err
+		}
+		p.Allow = append(p.Allow, r)
+	}
+	for _, text := range pc.Deny {
+		r, err := ParsePolicyRule(text)
+		if err != nil {
+			return nil, err
+		}
+		p.Deny = append(p.Deny, r)
+	}
+	return p, nil
+}
+
+// LoadPolicy reads the policy file from the config dir. Returns nil when
+// there is none, so every prompt is left to the user.
+func LoadPolicy() (*Policy, error) {
+	pc, err := config.LoadPolicy()
+	if err != nil || pc == nil {
+		return nil, err
+	}
+	return NewPolicy(*pc)
+}
+
+// Decide returns the action for a prompt and the rule that chose it.
+// Deny rules are checked against each command of a Bash chain, but chained
+// commands are never allowed by a rule: the pattern only vouches for the
+// start of the command. Nor are arguments that span several lines or were
+// cut off on screen, as more may follow the part that matched.
+func (p *Policy) Decide(prompt *PermissionPrompt) (PolicyAction, string) {
+	if p == nil || prompt == nil {
+		return PolicyNone, ""
+	}
+	for _, r := range p.Deny {
+		if r.Matches(prompt) {
+			return PolicyDeny, r.Text
+		}
+		// Deny rules also catch a command chained after another one
+		if prompt.Tool == "Bash" {
+			for _, part := range bashSegments(prompt.Argument) {
+				if r.Matches(&PermissionPrompt{Tool: prompt.Tool, Argument: part}) {
+					return PolicyDeny, r.Text
+				}
+			}
+		}
+	}
+	if prompt.Tool == "Bash" && containsAny(prompt.Argument, shellOperators) {
+		return PolicyNone, ""
+	}
+	if strings.Contains(prompt.Argument, "\n") || strings.HasSuffix(prompt.Argument, "…") {
+		return PolicyNone, ""
+	}
+	for _, r := range p.Allow {
+		if r.Matches(prompt) {
+			return PolicyAllow, r.Text
+		}
+	}
+	return PolicyNone, ""
+}
+
+// bashSegments splits a shell command at its operators, e.g.
+// "go test && rm -rf ~" into "go test" and "rm -rf ~".
+func bashSegments(cmd string) []string {
+	for _, op := range shellOperators {
+		cmd = strings.ReplaceAll(cmd, op, "\n")
+	}
+	var parts []string
+	for _, part := range strings.Split(cmd, "\n") {
+		if part = strings.TrimSpace(strings.TrimSuffix(part, ")")); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
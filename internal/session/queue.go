@@ -0,0 +1,84 @@
+package session
+
+import (
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// queueDeliveryTimeout is how long a delivered message may go without the
+// session starting to run before the next one is sent anyway.
+const queueDeliveryTimeout = 30 * time.Second
+
+// IsIdle reports whether a session is waiting for input and can take the
+// next queued message.
+func IsIdle(s Status) bool {
+	return s == Waiting || s == TaskDone
+}
+
+// QueueDispatcher decides when sessions get their next queued message.
+// After a delivery the session has to run (or the delivery time out)
+// before it gets another, so one message is sent per idle turn.
+type QueueDispatcher struct {
+	sentAt map[string]time.Time // state.QueueKey -> last delivery, until the session runs
+}
+
+// NewQueueDispatcher returns an empty QueueDispatcher.
+func NewQueueDispatcher() *QueueDispatcher {
+	return &QueueDispatcher{sentAt: make(map[string]time.Time)}
+}
+
+// Due returns the idle sessions with queued messages (depths by
+// state.QueueKey) that should get one now, and marks them as sent.
+func (q *QueueDispatcher) Due(sessions []Session, depths map[string]int, now time.Time) []Session {
+	var due []Session
+	active := make(map[string]bool)
+	for _, s := range sessions {
+		key := state.QueueKey(s.Host, s.FullName)
+		active[key] = true
+		if !IsIdle(s.Status) {
+			delete(q.sentAt, key)
+			continue
+		}
+		if depths[key] == 0 {
+			continue
+		}
+		if sent, ok := q.sentAt[key]; ok && now.Sub(sent) < queueDeliveryTimeout {
+			continue
+		}
+		q.sentAt[key] = now
+		due = append(due, s)
+	}
+	for key := range q.sentAt {
+		if !active[key] {
+			delete(q.sentAt, key)
+		}
+	}
+	return due
+}
+
+// Retry forgets a delivery that didn't happen, so the session is due again.
+func (q *QueueDispatcher) Retry(key string) {
+	delete(q.sentAt, key)
+}
+
+// DeliverQueued re-captures the pane to verify the session is still idle,
+// then sends its oldest queued message with SendMessage, which retries a
+// lost Enter, and removes it from the queue. Returns false with no error if the session was busy or the queue empty.
+func DeliverQueued(ex tmux.Executor, store *state.Store, fullName, host string) (state.QueuedMessage, bool, error) {
+	output, err := ex.CapturePaneOutput(fullName, 25)
+	if err == nil && !IsIdle(DetectStatus(output)) {
+		return state.QueuedMessage{}, false, nil
+	}
+	msg, ok := store.NextQueuedMessage(fullName, host)
+	if !ok {
+		return msg, false, nil
+	}
+	if err := SendMessage(ex, fullName, msg.Text); err != nil {
+		return msg, false, err
+	}
+	_ = store.DeleteQueuedMessage(msg.ID)
+	_ = store.RecordEvent(state.Event{Name: fullName, Host: host, Kind: state.EventSend, Detail: msg.Text})
+	return msg, true, nil
+}
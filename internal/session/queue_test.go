@@ -0,0 +1,141 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+func TestQueueDispatcherDue(t *testing.T) {
+	type step struct {
+		at       int               // seconds since start
+		statuses map[string]Status // crab name -> status; missing means gone
+		depths   map[string]int
+		want     []string
+	}
+	idle := map[string]Status{"crab-a": Waiting}
+	queued := map[string]int{"crab-a": 2}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "one message per idle turn",
+			steps: []step{
+				{0, idle, queued, []string{"crab-a"}},
+				{2, idle, queued, nil}, // delivered, not running yet
+				{4, map[string]Status{"crab-a": Running}, queued, nil},
+				{9, map[string]Status{"crab-a": TaskDone}, queued, []string{"crab-a"}},
+			},
+		},
+		{
+			name: "re-sent after the delivery timeout",
+			steps: []step{
+				{0, idle, queued, []string{"crab-a"}},
+				{29, idle, queued, nil},
+				{30, idle, queued, []string{"crab-a"}},
+			},
+		},
+		{
+			name: "nothing queued or busy",
+			steps: []step{
+				{0, idle, nil, nil},
+				{1, map[string]Status{"crab-a": Running, "crab-b": Permission}, map[string]int{"crab-a": 1, "crab-b": 1}, nil},
+			},
+		},
+		{
+			name: "gone sessions are forgotten",
+			steps: []step{
+				{0, idle, queued, []string{"crab-a"}},
+				{2, nil, queued, nil},
+				// a new session with the same name starts afresh
+				{4, idle, queued, []string{"crab-a"}},
+			},
+		},
+	}
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueueDispatcher()
+			for i, st := range tt.steps {
+				var sessions []Session
+				for _, name := range []string{"crab-a", "crab-b"} {
+					if status, ok := st.statuses[name]; ok {
+						sessions = append(sessions, Session{FullName: name, Status: status})
+					}
+				}
+				var got []string
+				for _, s := range q.Due(sessions, st.depths, t0.Add(time.Duration(st.at)*time.Second)) {
+					got = append(got, s.FullName)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(st.want) {
+					t.Errorf("step %d: due = %v, want %v", i, got, st.want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueDispatcherRetry(t *testing.T) {
+	q := NewQueueDispatcher()
+	now := time.Now()
+	sessions := []Session{{FullName: "crab-a", Host: "bay9", Status: Waiting}}
+	depths := map[string]int{state.QueueKey("bay9", "crab-a"): 1}
+
+	if due := q.Due(sessions, depths, now); len(due) != 1 {
+		t.Fatalf("due = %v, want crab-a", due)
+	}
+	if due := q.Due(sessions, depths, now.Add(time.Second)); len(due) != 0 {
+		t.Fatalf("due again before delivery: %v", due)
+	}
+	// The session turned out to be busy, so nothing was sent
+	q.Retry(state.QueueKey("bay9", "crab-a"))
+	if due := q.Due(sessions, depths, now.Add(2*time.Second)); len(due) != 1 {
+		t.Errorf("due after Retry = %v, want crab-a", due)
+	}
+}
+
+func TestMessageQueue(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	store, err := state.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, text := range []string{"first", "second"} {
+		if _, err := store.EnqueueMessage("crab-a", "", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := store.EnqueueMessage("crab-a", "bay9", "remote"); err != nil || n != 1 {
+		t.Fatalf("EnqueueMessage on bay9 = %d, %v; want 1", n, err)
+	}
+
+	depths, err := store.QueueDepths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depths["crab-a"] != 2 || depths["bay9:crab-a"] != 1 {
+		t.Errorf("depths = %v", depths)
+	}
+
+	msg, ok := store.NextQueuedMessage("crab-a", "")
+	if !ok || msg.Text != "first" {
+		t.Fatalf("next = %+v, %v; want first", msg, ok)
+	}
+	_ = store.DeleteQueuedMessage(msg.ID)
+	if msg, _ := store.NextQueuedMessage("crab-a", ""); msg.Text != "second" {
+		t.Errorf("next after delete = %q, want second", msg.Text)
+	}
+
+	_ = store.ClearQueue("crab-a", "")
+	if _, ok := store.NextQueuedMessage("crab-a", ""); ok {
+		t.Error("queue not cleared")
+	}
+	if _, ok := store.NextQueuedMessage("crab-a", "bay9"); !ok {
+		t.Error("clearing the local queue dropped bay9's")
+	}
+}
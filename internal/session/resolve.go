@@ -0,0 +1,59 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors for unknown hosts and sessions.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is wrapped by the error for creating a running session.
+	ErrExists = errors.New("already exists")
+	// ErrInvalid is wrapped by the errors for bad names, templates and
+	// launch settings.
+	ErrInvalid = errors.New("invalid")
+)
+
+var validNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidName reports whether name can be used for a new session: only
+// letters, digits, hyphens and underscores.
+func ValidName(name string) bool {
+	return validNameRe.MatchString(name)
+}
+
+// FindExecutor returns the executor for a host nickname ("" or "local" for
+// this machine).
+func FindExecutor(executors []tmux.Executor, host string) (tmux.Executor, error) {
+	if host == "local" {
+		host = ""
+	}
+	for _, ex := range executors {
+		if ex.HostName() == host {
+			return ex, nil
+		}
+	}
+	return nil, fmt.Errorf("host %q %w", host, ErrNotFound)
+}
+
+// Resolve finds the executor and full tmux name of a running session from
+// its "[host:]name" label.
+func Resolve(executors []tmux.Executor, label string) (tmux.Executor, string, error) {
+	host, name := ParseLabel(label)
+	if name == "" {
+		return nil, "", errors.New("session is required")
+	}
+	ex, err := FindExecutor(executors, host)
+	if err != nil {
+		return nil, "", err
+	}
+	fullName := ex.SessionPrefix() + name
+	if !ex.HasSession(fullName) {
+		return nil, "", fmt.Errorf("session %q %w", label, ErrNotFound)
+	}
+	return ex, fullName, nil
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestFindExecutor(t *testing.T) {
+	executors := []tmux.Executor{&tmux.LocalExecutor{}, &tmux.SSHExecutor{Nickname: "bay3"}}
+	for host, want := range map[string]string{"": "", "local": "", "bay3": "bay3"} {
+		ex, err := FindExecutor(executors, host)
+		if err != nil {
+			t.Fatalf("FindExecutor(%q): %v", host, err)
+		}
+		if ex.HostName() != want {
+			t.Errorf("FindExecutor(%q) = host %q, want %q", host, ex.HostName(), want)
+		}
+	}
+	if _, err := FindExecutor(executors, "bay9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown host: err = %v, want ErrNotFound", err)
+	}
+	if _, _, err := Resolve(executors, "bay9:fix-tests"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve on unknown host: err = %v, want ErrNotFound", err)
+	}
+	if _, _, err := Resolve(executors, "bay3:"); err == nil {
+		t.Error("Resolve without a name: expected an error")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"fix-tests": true,
+		"pr_123":    true,
+		"":          false,
+		"a b":       false,
+		"bay3:x":    false,
+		"../x":      false,
+	} {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// LoadResumable returns past sessions from the state DB, or with all set,
+// every recent Claude conversation on disk merged with the DB rows.
+// Sessions whose crab is still running are left out.
+func LoadResumable(store *state.Store, executors []tmux.Executor, all bool) ([]ClaudeSession, error) {
+	past, err := store.ListResumable(100)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make([]ClaudeSession, 0, len(past))
+	for _, ps := range past {
+		tracked = append(tracked, ClaudeSession{
+			Name:         ps.Name,
+			Host:         ps.Host,
+			UUID:         ps.SessionUUID,
+			ProjectDir:   ps.WorkDir,
+			ModTime:      ps.LastSeen,
+			FirstMessage: ps.FirstMsg,
+			Killed:       ps.Killed,
+			Tracked:      true,
+			ClaudeBin:    ps.ClaudeBin,
+			ClaudeFlags:  ps.ClaudeFlags,
+		})
+	}
+	sessions := tracked
+	if all {
+		var onDisk []ClaudeSession
+		for _, ex := range executors {
+			for _, cs := range ListRecentClaudeSessions(ex, 100) {
+				cs.Host = ex.HostName()
+				onDisk = append(onDisk, cs)
+			}
+		}
+		sessions = MergeClaudeSessions(tracked, onDisk)
+	}
+
+	active := make(map[string]bool)
+	for _, ex := range executors {
+		infos, _ := ex.ListSessions()
+		for _, info := range infos {
+			active[Label(ex.HostName(), info.FullName)] = true
+		}
+	}
+	var out []ClaudeSession
+	for _, cs := range sessions {
+		if cs.Name == "" || !active[Label(cs.Host, cs.Name)] {
+			out = append(out, cs)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+func TestLoadResumableByHost(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	store, err := state.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.SaveSessionUUID("crab-api", "", "uuid-local", "/src/api", "fix the tests"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkKilled("crab-api", "bay3", "uuid-bay3", "/home/me/api", "deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveClaudeFlags("crab-api", "bay3", "", "--model opus"); err != nil {
+		t.Fatal(err)
+	}
+
+	past, err := LoadResumable(store, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]ClaudeSession)
+	for _, cs := range past {
+		got[Label(cs.Host, cs.Name)] = cs
+	}
+	if cs := got["crab-api"]; cs.UUID != "uuid-local" || cs.Killed {
+		t.Errorf("local crab-api = %+v, want uuid-local, not killed", cs)
+	}
+	if cs := got["bay3:crab-api"]; cs.UUID != "uuid-bay3" || !cs.Killed || cs.ClaudeFlags != "--model opus" {
+		t.Errorf("bay3:crab-api = %+v, want uuid-bay3, killed, with flags", cs)
+	}
+}
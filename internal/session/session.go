@@ -1,13 +1,18 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/simon/crabctl/internal/state"
 	"github.com/simon/crabctl/internal/tmux"
 )
 
@@ -39,6 +44,26 @@ func (s Status) String() string {
 	}
 }
 
+// ParseStatus converts a status name (as printed by String) back to a Status.
+// Accepts hyphens in place of spaces, e.g. "task-done".
+func ParseStatus(s string) (Status, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", " ") {
+	case "running":
+		return Running, nil
+	case "waiting":
+		return Waiting, nil
+	case "permission":
+		return Permission, nil
+	case "confirm":
+		return Confirm, nil
+	case "task done":
+		return TaskDone, nil
+	case "unknown":
+		return Unknown, nil
+	}
+	return Unknown, fmt.Errorf("unknown status %q", s)
+}
+
 type Session struct {
 	Name            string
 	FullName        string
@@ -54,13 +79,51 @@ type Session struct {
 	LastActive      time.Time // most recent Claude session file mtime
 	AttachedCount   int
 	WorkDir         string
-	PaneContent     string // latest captured pane output (for UUID matching)
-	SessionUUID     string // matched Claude session file UUID
-	SessionFirstMsg string // first user message from matched session
+	RepoDir         string            // git repo of WorkDir; for a crabctl worktree, the repo it was made from
+	PaneContent     string            // latest captured pane output (for UUID matching)
+	SessionUUID     string            // matched Claude session file UUID
+	SessionFirstMsg string            // first user message from matched session
+	Prompt          *PermissionPrompt // permission dialog on screen, if any
+	Menu            *Menu             // other numbered menu on screen (e.g. plan approval)
+	Branch          string            // branch of the worktree crabctl created for the session
+	Usage           UsageTotals       // token usage and cost of the matched session
+	UsageReadAt     time.Time         // when Usage was last read from the session file
+}
+
+// Label returns the "[host:]name" form of a session that users type and
+// see, e.g. "bay9:fix-tests" or "fix-tests" for a local one.
+func Label(host, name string) string {
+	if host == "" {
+		return name
+	}
+	return host + ":" + name
+}
+
+// ParseLabel splits "host:name" into (host, name).
+// If no colon, returns ("", name).
+func ParseLabel(s string) (host, name string) {
+	if idx := strings.IndexByte(s, ':'); idx >= 0 {
+		return s[:idx], s[idx+1:]
+	}
+	return "", s
+}
+
+// HostLabel returns the display name of a host ("local" for the local machine).
+func HostLabel(host string) string {
+	if host == "" {
+		return "local"
+	}
+	return host
 }
 
-// List returns all crab-* sessions with status detection.
-func List() ([]Session, error) {
+// Label returns the session's "[host:]name".
+func (s Session) Label() string {
+	return Label(s.Host, s.Name)
+}
+
+// List returns all crab-* sessions with status detection, applying hooks
+// (see ApplyHookEvents; may be nil).
+func List(hooks map[string]state.HookEvent) ([]Session, error) {
 	infos, err := tmux.ListSessions()
 	if err != nil {
 		return nil, err
@@ -71,6 +134,7 @@ func List() ([]Session, error) {
 		output, _ := tmux.CapturePaneOutput(info.FullName, 25)
 		status, bar, lastAction := analyzeOutput(output)
 		workDir := tmux.GetPanePath(info.FullName)
+		prompt, menu := parseMenus(output)
 
 		sessions = append(sessions, Session{
 			Name:          info.Name,
@@ -86,14 +150,18 @@ func List() ([]Session, error) {
 			AttachedCount: info.AttachedCount,
 			WorkDir:       workDir,
 			PaneContent:   output,
+			Prompt:        prompt,
+			Menu:          menu,
 		})
 	}
+	ApplyHookEvents(sessions, hooks, time.Now())
 	SortSessions(sessions)
 	return sessions, nil
 }
 
-// ListExecutor returns sessions from a single executor.
-func ListExecutor(ex tmux.Executor) ([]Session, error) {
+// ListExecutor returns sessions from a single executor. hooks are the hook
+// events of local sessions, loaded once per poll by the caller (may be nil).
+func ListExecutor(ex tmux.Executor, hooks map[string]state.HookEvent) ([]Session, error) {
 	host := ex.HostName()
 
 	infos, err := ex.ListSessions()
@@ -106,6 +174,7 @@ func ListExecutor(ex tmux.Executor) ([]Session, error) {
 		output, _ := ex.CapturePaneOutput(info.FullName, 25)
 		status, bar, lastAction := analyzeOutput(output)
 		workDir := ex.GetPanePath(info.FullName)
+		prompt, menu := parseMenus(output)
 
 		var prURL string
 		if host == "" {
@@ -127,11 +196,58 @@ func ListExecutor(ex tmux.Executor) ([]Session, error) {
 			AttachedCount: info.AttachedCount,
 			WorkDir:       workDir,
 			PaneContent:   output,
+			Prompt:        prompt,
+			Menu:          menu,
 		})
 	}
+	if host == "" {
+		ApplyHookEvents(sessions, hooks, time.Now())
+	}
 	return sessions, nil
 }
 
+// ListAll queries every executor in parallel and returns the combined,
+// sorted session list. Hosts that fail are left out and reported in the
+// error. hooks are passed on to ListExecutor.
+func ListAll(executors []tmux.Executor, hooks map[string]state.HookEvent) ([]Session, error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		all  []Session
+		errs []error
+	)
+	for _, ex := range executors {
+		wg.Add(1)
+		go func(ex tmux.Executor) {
+			defer wg.Done()
+			sessions, err := ListExecutor(ex, hooks)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				host := ex.HostName()
+				if host == "" {
+					host = "local"
+				}
+				errs = append(errs, fmt.Errorf("%s: %w", host, err))
+				return
+			}
+			all = append(all, sessions...)
+		}(ex)
+	}
+	wg.Wait()
+	SortSessions(all)
+	return all, errors.Join(errs...)
+}
+
+// parseMenus parses the permission dialog or, failing that, any other
+// numbered menu at the bottom of the pane.
+func parseMenus(output string) (*PermissionPrompt, *Menu) {
+	if p := ParsePermissionPrompt(output); p != nil {
+		return p, nil
+	}
+	return nil, ParseMenu(output)
+}
+
 // statusPriority returns sort priority (lower = more important, shown first).
 func statusPriority(s Status) int {
 	switch s {
@@ -171,6 +287,57 @@ func DetectStatus(output string) Status {
 	return detectStatus(strings.Split(output, "\n"))
 }
 
+// IsRunningIndicator reports whether a pane line is Claude's live progress
+// spinner (e.g. "✻ Thinking…"), which is redrawn in place while running.
+func IsRunningIndicator(line string) bool {
+	return isRunningIndicator(strings.TrimSpace(line))
+}
+
+// CleanPaneOutput strips Claude's TUI decoration from captured pane output.
+// Lines may still carry ANSI styling; matching is done on the plain text.
+func CleanPaneOutput(output string) string {
+	lines := strings.Split(output, "\n")
+	var cleaned []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(ansi.Strip(line))
+
+		// Skip empty lines at the start
+		if len(cleaned) == 0 && trimmed == "" {
+			continue
+		}
+
+		// Skip status bar lines
+		if strings.Contains(trimmed, "bypass permissions") ||
+			strings.Contains(trimmed, "shift+tab") ||
+			strings.Contains(trimmed, "auto-accept") ||
+			strings.Contains(trimmed, "plan mode") ||
+			strings.Contains(trimmed, "esc to interrupt") ||
+			strings.Contains(trimmed, "for shortcuts") {
+			continue
+		}
+
+		// Skip box-drawing borders (╭, ╰)
+		if strings.HasPrefix(trimmed, "╭") ||
+			strings.HasPrefix(trimmed, "╰") {
+			continue
+		}
+
+		// Skip pure horizontal rules
+		if trimmed != "" && strings.TrimLeft(trimmed, "─") == "" {
+			continue
+		}
+
+		cleaned = append(cleaned, line)
+	}
+
+	// Trim trailing empty lines
+	for len(cleaned) > 0 && strings.TrimSpace(ansi.Strip(cleaned[len(cleaned)-1])) == "" {
+		cleaned = cleaned[:len(cleaned)-1]
+	}
+
+	return strings.Join(cleaned, "\n")
+}
+
 type statusBarInfo struct {
 	Mode       string
 	GitChanges string
@@ -241,6 +408,10 @@ func detectStatus(lines []string) Status {
 		if isPermissionLine(trimmed) {
 			return Permission
 		}
+		// "Do you want to proceed?" above a numbered menu = tool permission
+		if sawNumberedMenu && isPermissionQuestion(trimmed) {
+			return Permission
+		}
 		// Numbered menu items (plan approval: "1. Yes, ...", "❯ 1. Yes, ...")
 		if isNumberedMenuItem(trimmed) {
 			sawNumberedMenu = true
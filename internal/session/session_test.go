@@ -133,6 +133,19 @@ func TestDetectStatus(t *testing.T) {
   ? for shortcuts`,
 			expect: Waiting,
 		},
+		{
+			name: "numbered permission dialog",
+			input: ` Bash command
+
+   go test ./...
+   Run the tests
+
+ Do you want to proceed?
+ ❯ 1. Yes
+   2. Yes, and don't ask again for go test commands in /src/foo
+   3. No, and tell Claude what to do differently (esc)`,
+			expect: Permission,
+		},
 		{
 			name: "plan approval menu with ❯ selector",
 			input: `╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌╌
@@ -394,3 +407,49 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect Status
+		ok     bool
+	}{
+		{"running", Running, true},
+		{"Waiting", Waiting, true},
+		{"task done", TaskDone, true},
+		{"task-done", TaskDone, true},
+		{"confirm", Confirm, true},
+		{"bogus", Unknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseStatus(tt.input)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseStatus(%q) error = %v, want ok=%v", tt.input, err, tt.ok)
+			}
+			if got != tt.expect {
+				t.Errorf("ParseStatus(%q) = %v, want %v", tt.input, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		host, name, label string
+	}{
+		{"", "fix-tests", "fix-tests"},
+		{"bay9", "fix-tests", "bay9:fix-tests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := Label(tt.host, tt.name); got != tt.label {
+				t.Errorf("Label(%q, %q) = %q, want %q", tt.host, tt.name, got, tt.label)
+			}
+			host, name := ParseLabel(tt.label)
+			if host != tt.host || name != tt.name {
+				t.Errorf("ParseLabel(%q) = %q, %q", tt.label, host, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package session
+
+import (
+	"sort"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+// StatusStats sums where the time of one or more sessions went.
+type StatusStats struct {
+	Sessions     int
+	Running      time.Duration
+	Waiting      time.Duration // for input, or after TASK DONE
+	Blocked      time.Duration // on a permission prompt or confirmation
+	AutoForwards int
+	Sends        int           // messages sent by hand or from the queue
+	Done         int           // sessions that said TASK DONE
+	ToDone       time.Duration // start to first TASK DONE, summed over Done
+}
+
+// Add adds o to the totals.
+func (t *StatusStats) Add(o StatusStats) {
+	t.Sessions += o.Sessions
+	t.Running += o.Running
+	t.Waiting += o.Waiting
+	t.Blocked += o.Blocked
+	t.AutoForwards += o.AutoForwards
+	t.Sends += o.Sends
+	t.Done += o.Done
+	t.ToDone += o.ToDone
+}
+
+// AvgToDone returns how long sessions took to say TASK DONE on average, or
+// 0 if none did.
+func (t StatusStats) AvgToDone() time.Duration {
+	if t.Done == 0 {
+		return 0
+	}
+	return t.ToDone / time.Duration(t.Done)
+}
+
+// Stalled returns the time spent waiting or blocked rather than running.
+func (t StatusStats) Stalled() time.Duration {
+	return t.Waiting + t.Blocked
+}
+
+// StalledPercent returns the share of the time that was stalled.
+func (t StatusStats) StalledPercent() int {
+	total := t.Running + t.Stalled()
+	if total == 0 {
+		return 0
+	}
+	return int(100 * t.Stalled() / total)
+}
+
+// SessionStats are the stats of one session, identified as in the history.
+type SessionStats struct {
+	Name    string // tmux session name
+	Host    string
+	WorkDir string
+	RepoDir string
+	StatusStats
+}
+
+// Repo returns the git repo the session worked in, or its directory for
+// history recorded before repos were.
+func (s SessionStats) Repo() string {
+	if s.RepoDir != "" {
+		return s.RepoDir
+	}
+	return s.WorkDir
+}
+
+// ComputeStats sums the history, oldest first, per session over the window
+// from since to now. Status times are clipped to the window, so the history
+// should reach back before since for the status sessions were in then.
+// Time to TASK DONE runs from the session's creation or resume, or its
+// first recorded status, and counts when TASK DONE falls in the window.
+//
+// live are the sessions running now. The last status of a session that
+// isn't among them, with no end recorded because nothing was watching when
+// it went, lasts until its last event rather than until now.
+func ComputeStats(events []state.Event, live []Session, since, now time.Time) []SessionStats {
+	type tally struct {
+		SessionStats
+		active  bool      // anything happened in the window
+		started time.Time // creation, resume or first status
+		done    bool      // said TASK DONE since started
+		seen    time.Time // last event
+	}
+	var order []string
+	byKey := make(map[string]*tally)
+	get := func(ev state.Event) *tally {
+		key := ev.Host + ":" + ev.Name
+		t := byKey[key]
+		if t == nil {
+			t = &tally{SessionStats: SessionStats{Name: ev.Name, Host: ev.Host}}
+			byKey[key] = t
+			order = append(order, key)
+		}
+		if ev.WorkDir != "" {
+			t.WorkDir = ev.WorkDir
+		}
+		if ev.RepoDir != "" {
+			t.RepoDir = ev.RepoDir
+		}
+		return t
+	}
+
+	for _, ev := range events {
+		t := get(ev)
+		t.seen = ev.Time
+		inWindow := !ev.Time.Before(since) && !ev.Time.After(now)
+		switch ev.Kind {
+		case state.EventNew, state.EventResume:
+			t.started, t.done = ev.Time, false
+		case state.EventStatus:
+			if t.started.IsZero() {
+				t.started = ev.Time
+			}
+			if ev.Status == TaskDone.String() && !t.done {
+				t.done = true
+				if inWindow {
+					t.Done++
+					t.ToDone += ev.Time.Sub(t.started)
+				}
+			}
+		case state.EventAutoForward:
+			if inWindow {
+				t.AutoForwards++
+			}
+		case state.EventSend:
+			if inWindow {
+				t.Sends++
+			}
+		}
+		if inWindow {
+			t.active = true
+		}
+	}
+
+	running := make(map[string]bool)
+	for _, s := range live {
+		running[s.Host+":"+s.FullName] = true
+	}
+	for _, sp := range Spans(events, now) {
+		key := sp.Host + ":" + sp.Name
+		start, end := sp.Start, sp.End
+		if sp.Open && !running[key] {
+			end = byKey[key].seen
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if !end.After(start) {
+			continue
+		}
+		d := end.Sub(start)
+		t := byKey[key]
+		t.active = true
+		switch sp.Status {
+		case Running.String():
+			t.Running += d
+		case Waiting.String(), TaskDone.String():
+			t.Waiting += d
+		case Permission.String(), Confirm.String():
+			t.Blocked += d
+		}
+	}
+
+	var out []SessionStats
+	for _, key := range order {
+		if t := byKey[key]; t.active {
+			t.Sessions = 1
+			out = append(out, t.SessionStats)
+		}
+	}
+	return out
+}
+
+// GroupStats are the summed stats of the sessions sharing a key.
+type GroupStats struct {
+	Key string
+	StatusStats
+}
+
+// GroupStatsBy sums stats by the key each session maps to, e.g. its repo,
+// and returns the groups most stalled first.
+func GroupStatsBy(stats []SessionStats, key func(SessionStats) string) []GroupStats {
+	var groups []GroupStats
+	index := make(map[string]int)
+	for _, s := range stats {
+		k := key(s)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, GroupStats{Key: k})
+		}
+		groups[i].Add(s.StatusStats)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if a, b := groups[i].Stalled(), groups[j].Stalled(); a != b {
+			return a > b
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
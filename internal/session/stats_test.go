@@ -0,0 +1,85 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+)
+
+func TestComputeStats(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+	events := []state.Event{
+		// a started before the window and is done inside it
+		{ID: 1, Name: "crab-a", Kind: state.EventNew, WorkDir: "/src/app", Time: at(0)},
+		{ID: 2, Name: "crab-a", Kind: state.EventStatus, Status: "running", Time: at(0)},
+		{ID: 3, Name: "crab-a", Kind: state.EventStatus, Status: "permission", Previous: "running", Time: at(20)},
+		{ID: 4, Name: "crab-a", Kind: state.EventStatus, Status: "running", Previous: "permission", Time: at(30)},
+		{ID: 5, Name: "crab-a", Kind: state.EventStatus, Status: "waiting", Previous: "running", Time: at(40)},
+		{ID: 6, Name: "crab-a", Kind: state.EventAutoForward, Time: at(41)},
+		{ID: 7, Name: "crab-a", Kind: state.EventStatus, Status: "running", Previous: "waiting", Time: at(41)},
+		{ID: 8, Name: "crab-a", Kind: state.EventStatus, Status: "task done", Previous: "running", Time: at(50)},
+		{ID: 9, Name: "crab-a", Kind: state.EventKill, Time: at(55)},
+		// b works in a worktree of a's repo, which a's history predates, and
+		// is still waiting
+		{ID: 10, Name: "crab-b", Kind: state.EventStatus, Status: "waiting", WorkDir: "/wt/app/crab-b", RepoDir: "/src/app", Time: at(45)},
+		{ID: 11, Name: "crab-b", Kind: state.EventSend, Time: at(50)},
+		// c was over before the window
+		{ID: 12, Name: "crab-c", Kind: state.EventStatus, Status: "running", WorkDir: "/src/lib", Time: at(0)},
+		{ID: 13, Name: "crab-c", Kind: state.EventEnd, Time: at(5)},
+	}
+	live := []Session{{FullName: "crab-b"}}
+	stats := ComputeStats(events, live, at(10), at(60))
+	if len(stats) != 2 {
+		t.Fatalf("got %d sessions, want 2: %+v", len(stats), stats)
+	}
+
+	a := stats[0]
+	if a.Name != "crab-a" || a.WorkDir != "/src/app" {
+		t.Fatalf("first session = %+v", a)
+	}
+	want := StatusStats{
+		Sessions:     1,
+		Running:      (10 + 10 + 9) * time.Minute, // from the start of the window
+		Waiting:      (1 + 5) * time.Minute,       // including after TASK DONE
+		Blocked:      10 * time.Minute,
+		AutoForwards: 1,
+		Done:         1,
+		ToDone:       50 * time.Minute,
+	}
+	if a.StatusStats != want {
+		t.Errorf("crab-a stats = %+v, want %+v", a.StatusStats, want)
+	}
+
+	b := stats[1]
+	if b.Waiting != 15*time.Minute || b.Sends != 1 || b.Done != 0 {
+		t.Errorf("crab-b stats = %+v", b.StatusStats)
+	}
+
+	groups := GroupStatsBy(stats, func(s SessionStats) string { return s.Repo() })
+	if len(groups) != 1 || groups[0].Sessions != 2 || groups[0].Stalled() != 31*time.Minute {
+		t.Errorf("groups = %+v", groups)
+	}
+	if p := groups[0].StalledPercent(); p != 51 {
+		t.Errorf("StalledPercent() = %d, want 51", p)
+	}
+}
+
+func TestComputeStatsGoneUnseen(t *testing.T) {
+	now := time.Date(2026, 10, 6, 9, 0, 0, 0, time.UTC)
+	events := []state.Event{
+		// d went while nothing was recording, so its end is missing
+		{ID: 1, Name: "crab-d", Kind: state.EventStatus, Status: "running", Time: now.Add(-120 * time.Hour)},
+		{ID: 2, Name: "crab-d", Kind: state.EventSend, Time: now.Add(-119 * time.Hour)},
+	}
+	stats := ComputeStats(events, nil, now.Add(-7*24*time.Hour), now)
+	if len(stats) != 1 || stats[0].Running != time.Hour {
+		t.Errorf("stats = %+v, want crab-d running until its last event (1h)", stats)
+	}
+
+	live := []Session{{FullName: "crab-d"}}
+	if stats := ComputeStats(events, live, now.Add(-7*24*time.Hour), now); len(stats) != 1 || stats[0].Running != 120*time.Hour {
+		t.Errorf("live stats = %+v, want crab-d running until now", stats)
+	}
+}
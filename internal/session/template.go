@@ -0,0 +1,28 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/simon/crabctl/internal/config"
+)
+
+// TemplateMessage returns the initial message for a session created from
+// t: its Message rendered with the session name and arg, or arg itself
+// when the template has no message.
+func TemplateMessage(t config.Template, name, arg string) (string, error) {
+	if t.Message == "" {
+		return arg, nil
+	}
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(t.Message)
+	if err != nil {
+		return "", fmt.Errorf("%w template message: %w", ErrInvalid, err)
+	}
+	var b strings.Builder
+	data := struct{ Name, Arg string }{name, arg}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w template message: %w", ErrInvalid, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/simon/crabctl/internal/config"
+)
+
+func TestTemplateMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		arg     string
+		want    string
+		err     bool
+	}{
+		{"placeholders", "Review PR for {{.Name}}: {{.Arg}}\n", "focus on tests", "Review PR for my-pr-123: focus on tests", false},
+		{"no message uses arg", "", "fix the flaky test", "fix the flaky test", false},
+		{"unknown field", "{{.Branch}}", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TemplateMessage(config.Template{Message: tt.message}, "my-pr-123", tt.arg)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// EntryKind is the kind of a transcript entry.
+type EntryKind int
+
+const (
+	EntryUser       EntryKind = iota // text typed by the user
+	EntryAssistant                   // text written by Claude
+	EntryToolCall                    // tool invocation by Claude
+	EntryToolResult                  // output of a tool invocation
+)
+
+// TranscriptEntry is one message or content block of a conversation.
+type TranscriptEntry struct {
+	Kind    EntryKind
+	Time    time.Time
+	Summary string // tool calls: e.g. "Bash(go test ./...)"; results: first line
+	Text    string // message text, tool input as JSON, or tool output
+	IsError bool   // tool result reported an error
+}
+
+// transcriptBlock is a content block of a JSONL message.
+type transcriptBlock struct {
+	Type    string                 `json:"type"`
+	Text    string                 `json:"text"`
+	Name    string                 `json:"name"`
+	Input   map[string]interface{} `json:"input"`
+	Content json.RawMessage        `json:"content"`
+	IsError bool                   `json:"is_error"`
+}
+
+// ReadTranscript reads the whole conversation of a Claude session file,
+// including tool calls and their results. Thinking blocks, meta messages
+// and subagent (sidechain) messages are left out.
+func ReadTranscript(fsys tmux.FileSystem, workDir, uuid string) ([]TranscriptEntry, error) {
+	projectsDir, err := claudeProjectsDir(fsys)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(projectsDir, encodeProjectDir(workDir), uuid+".jsonl")
+
+	f, err := fsys.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 256*1024), 16*1024*1024)
+
+	var entries []TranscriptEntry
+	for scanner.Scan() {
+		var msg struct {
+			Type        string `json:"type"`
+			Timestamp   string `json:"timestamp"`
+			IsMeta      bool   `json:"isMeta"`
+			IsSidechain bool   `json:"isSidechain"`
+			Message     struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if (msg.Type != "user" && msg.Type != "assistant") || msg.IsMeta || msg.IsSidechain {
+			continue
+		}
+		ts, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+
+		textKind := EntryUser
+		if msg.Type == "assistant" {
+			textKind = EntryAssistant
+		}
+
+		var blocks []transcriptBlock
+		var s string
+		if json.Unmarshal(msg.Message.Content, &s) == nil {
+			blocks = []transcriptBlock{{Type: "text", Text: s}}
+		} else if json.Unmarshal(msg.Message.Content, &blocks) != nil {
+			continue
+		}
+
+		for _, b := range blocks {
+			switch b.Type {
+			case "text":
+				text := strings.TrimSpace(b.Text)
+				if text == "" || strings.HasPrefix(text, "<command-message>") {
+					continue
+				}
+				entries = append(entries, TranscriptEntry{Kind: textKind, Time: ts, Text: text})
+			case "tool_use":
+				input, _ := json.MarshalIndent(b.Input, "", "  ")
+				entries = append(entries, TranscriptEntry{
+					Kind:    EntryToolCall,
+					Time:    ts,
+					Summary: hookToolSummary(b.Name, b.Input),
+					Text:    string(input),
+				})
+			case "tool_result":
+				output := strings.TrimRight(extractToolResult(b.Content), "\n")
+				entries = append(entries, TranscriptEntry{
+					Kind:    EntryToolResult,
+					Time:    ts,
+					Summary: toolResultSummary(output),
+					Text:    output,
+					IsError: b.IsError,
+				})
+			}
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// extractToolResult gets the text of a tool_result content field, which is
+// either a string or an array of text (and image) blocks.
+func extractToolResult(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var blocks []transcriptBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			parts = append(parts, b.Text)
+		case "image":
+			parts = append(parts, "[image]")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolResultSummary returns the first line of a tool's output and how many
+// lines follow, e.g. "ok  ./... (+12 lines)".
+func toolResultSummary(output string) string {
+	if output == "" {
+		return "(no output)"
+	}
+	lines := strings.Split(output, "\n")
+	first := strings.TrimSpace(lines[0])
+	if r := []rune(first); len(r) > 80 {
+		first = string(r[:77]) + "..."
+	}
+	if len(lines) > 1 {
+		first += fmt.Sprintf(" (+%d lines)", len(lines)-1)
+	}
+	return first
+}
+
+// TranscriptText formats a transcript as plain text. Tool calls and results
+// are shown as one-line summaries unless expanded is set.
+func TranscriptText(entries []TranscriptEntry, expanded bool) string {
+	var b strings.Builder
+	for i, e := range entries {
+		switch e.Kind {
+		case EntryUser:
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("You: " + e.Text + "\n")
+		case EntryAssistant:
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("Claude: " + e.Text + "\n")
+		case EntryToolCall:
+			b.WriteString("⏺ " + e.Summary + "\n")
+			if expanded {
+				b.WriteString(indent(e.Text, "    ") + "\n")
+			}
+		case EntryToolResult:
+			if expanded {
+				b.WriteString(indent(e.Text, "  ⎿ ") + "\n")
+			} else {
+				b.WriteString("  ⎿ " + e.Summary + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// TranscriptMarkdown formats a transcript as a Markdown document with tool
+// calls and results in collapsible <details> blocks.
+func TranscriptMarkdown(title string, entries []TranscriptEntry) string {
+	var b strings.Builder
+	b.WriteString("# " + title + "\n")
+	for _, e := range entries {
+		switch e.Kind {
+		case EntryUser:
+			b.WriteString("\n## You\n\n" + e.Text + "\n")
+		case EntryAssistant:
+			b.WriteString("\n## Claude\n\n" + e.Text + "\n")
+		case EntryToolCall:
+			b.WriteString("\n<details><summary>⏺ " + htmlEscaper.Replace(e.Summary) + "</summary>\n\n")
+			b.WriteString(codeFence(e.Text, "json"))
+			b.WriteString("\n</details>\n")
+		case EntryToolResult:
+			label := "Result"
+			if e.IsError {
+				label = "Error"
+			}
+			b.WriteString("\n<details><summary>" + label + ": " + htmlEscaper.Replace(e.Summary) + "</summary>\n\n")
+			b.WriteString(codeFence(e.Text, ""))
+			b.WriteString("\n</details>\n")
+		}
+	}
+	return b.String()
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// codeFence wraps s in a fenced code block longer than any backtick run in s.
+func codeFence(s, lang string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + s + "\n" + fence + "\n"
+}
+
+// indent prefixes every line of s.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// TranscriptFile locates the Claude session file of the running session
+// fullName on ex. The UUID saved in store once it was resolved is used if
+// its file was written since the tmux session started, as a saved UUID may
+// belong to an earlier session of the same name. Failing that, the session
+// files are matched against the session as on first discovery.
+func TranscriptFile(ex tmux.Executor, store *state.Store, fullName string) (workDir, uuid string, err error) {
+	sessions, err := ListExecutor(ex, nil)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range sessions {
+		if s.FullName != fullName {
+			continue
+		}
+		started := time.Now().Add(-s.Duration)
+		if saved, ok := store.GetSession(fullName, ex.HostName()); ok &&
+			!SessionFileModTime(ex, saved.WorkDir, saved.SessionUUID).Before(started.Truncate(time.Second)) {
+			return saved.WorkDir, saved.SessionUUID, nil
+		}
+		uuid, _ := FindSessionUUID(ex, s.WorkDir, started, s.PaneContent, nil)
+		if uuid == "" {
+			return "", "", fmt.Errorf("no Claude session file found for %q", Label(ex.HostName(), fullName))
+		}
+		return s.WorkDir, uuid, nil
+	}
+	return "", "", fmt.Errorf("session %q %w", Label(ex.HostName(), fullName), ErrNotFound)
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestReadTranscript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "projects", "-src-foo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonl := `{"type":"user","isMeta":true,"message":{"content":"Caveat: meta"}}
+{"type":"user","message":{"content":"run the tests"}}
+{"type":"assistant","message":{"content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Running them."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok  a\nok  b\n"}]}}
+{"type":"assistant","isSidechain":true,"message":{"content":[{"type":"text","text":"subagent"}]}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"All green."}]}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "uuid-1.jsonl"), []byte(jsonl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadTranscript(&tmux.LocalExecutor{}, "/src/foo", "uuid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		kind    EntryKind
+		summary string
+		text    string
+	}{
+		{EntryUser, "", "run the tests"},
+		{EntryAssistant, "", "Running them."},
+		{EntryToolCall, "Bash(go test ./...)", "{\n  \"command\": \"go test ./...\"\n}"},
+		{EntryToolResult, "ok  a (+1 lines)", "ok  a\nok  b"},
+		{EntryAssistant, "", "All green."},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Kind != w.kind || e.Summary != w.summary || e.Text != w.text {
+			t.Errorf("entry %d = %+v, want %+v", i, e, w)
+		}
+	}
+
+	if _, err := ReadTranscript(&tmux.LocalExecutor{}, "/src/foo", "missing"); err == nil {
+		t.Error("expected error for missing session file")
+	}
+}
+
+func TestTranscriptMarkdownFence(t *testing.T) {
+	md := TranscriptMarkdown("t", []TranscriptEntry{
+		{Kind: EntryToolResult, Summary: "<b>", Text: "```go\nx\n```"},
+	})
+	if !strings.Contains(md, "````\n```go\nx\n```\n````") {
+		t.Errorf("result not fenced with a longer fence:\n%s", md)
+	}
+	if !strings.Contains(md, "Result: &lt;b&gt;") {
+		t.Errorf("summary not escaped:\n%s", md)
+	}
+}
@@ -0,0 +1,247 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// TokenUsage counts the tokens of one or more assistant messages.
+type TokenUsage struct {
+	Input      int64
+	Output     int64
+	CacheWrite int64 // cache_creation_input_tokens
+	CacheRead  int64 // cache_read_input_tokens
+}
+
+// UsageRecord is the token usage of a single assistant message.
+type UsageRecord struct {
+	SessionUUID string
+	CWD         string
+	Model       string
+	Time        time.Time
+	TokenUsage
+}
+
+// UsageTotals sums the usage and estimated cost of several messages.
+type UsageTotals struct {
+	TokenUsage
+	Messages int
+	Cost     float64 // USD
+}
+
+// Add adds a message's usage, priced with p, to the totals.
+func (t *UsageTotals) Add(r UsageRecord, p Pricing) {
+	t.Input += r.Input
+	t.Output += r.Output
+	t.CacheWrite += r.CacheWrite
+	t.CacheRead += r.CacheRead
+	t.Messages++
+	t.Cost += p.Cost(r.Model, r.TokenUsage)
+}
+
+// Pricing maps model name fragments to prices. The longest key contained
+// in a model name wins, so "opus-4-5" overrides "opus".
+type Pricing map[string]config.ModelPrice
+
+// defaultPricing holds Anthropic list prices in USD per million tokens.
+var defaultPricing = Pricing{
+	"opus":      {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"opus-4-5":  {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"sonnet":    {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"haiku":     {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	"haiku-4-5": {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+}
+
+// LoadPricing returns the built-in price table with overrides from the
+// "prices" section of the config file applied.
+func LoadPricing() Pricing {
+	p := make(Pricing, len(defaultPricing))
+	for k, v := range defaultPricing {
+		p[k] = v
+	}
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		for k, v := range cfg.Prices {
+			p[strings.ToLower(k)] = v
+		}
+	}
+	return p
+}
+
+// Cost returns the estimated USD cost of u for the given model.
+// Unknown models cost nothing.
+func (p Pricing) Cost(model string, u TokenUsage) float64 {
+	model = strings.ToLower(model)
+	var price config.ModelPrice
+	best := -1
+	for k, v := range p {
+		if strings.Contains(model, k) && len(k) > best {
+			price, best = v, len(k)
+		}
+	}
+	if best < 0 {
+		return 0
+	}
+	return (float64(u.Input)*price.Input +
+		float64(u.Output)*price.Output +
+		float64(u.CacheWrite)*price.CacheWrite +
+		float64(u.CacheRead)*price.CacheRead) / 1e6
+}
+
+// readSessionUsage reads the usage of every assistant message in a JSONL
+// session file. Claude Code writes one line per content block, all carrying
+// the message's usage, so lines are deduplicated by message ID (last wins).
+func readSessionUsage(fsys tmux.FileSystem, path string) []UsageRecord {
+	f, err := fsys.OpenFile(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	// Assistant lines carrying large tool inputs easily exceed the 256k
+	// used for metadata scans, so allow much longer lines here.
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 256*1024), 16*1024*1024)
+
+	var records []UsageRecord
+	byID := make(map[string]int)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.Contains(line, []byte(`"usage"`)) {
+			continue
+		}
+		var msg struct {
+			Type      string `json:"type"`
+			SessionID string `json:"sessionId"`
+			CWD       string `json:"cwd"`
+			Timestamp string `json:"timestamp"`
+			Message   struct {
+				ID    string `json:"id"`
+				Model string `json:"model"`
+				Usage *struct {
+					Input      int64 `json:"input_tokens"`
+					Output     int64 `json:"output_tokens"`
+					CacheWrite int64 `json:"cache_creation_input_tokens"`
+					CacheRead  int64 `json:"cache_read_input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+		if msg.Type != "assistant" || msg.Message.Usage == nil {
+			continue
+		}
+
+		u := msg.Message.Usage
+		rec := UsageRecord{
+			SessionUUID: msg.SessionID,
+			CWD:         msg.CWD,
+			Model:       msg.Message.Model,
+			TokenUsage: TokenUsage{
+				Input:      u.Input,
+				Output:     u.Output,
+				CacheWrite: u.CacheWrite,
+				CacheRead:  u.CacheRead,
+			},
+		}
+		rec.Time, _ = time.Parse(time.RFC3339Nano, msg.Timestamp)
+
+		if msg.Message.ID != "" {
+			if i, ok := byID[msg.Message.ID]; ok {
+				records[i] = rec
+				continue
+			}
+			byID[msg.Message.ID] = len(records)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// SessionUsage returns the usage totals of a Claude session file.
+func SessionUsage(fsys tmux.FileSystem, workDir, uuid string, p Pricing) UsageTotals {
+	var totals UsageTotals
+	if workDir == "" || uuid == "" {
+		return totals
+	}
+	projectsDir, err := claudeProjectsDir(fsys)
+	if err != nil {
+		return totals
+	}
+	path := filepath.Join(projectsDir, encodeProjectDir(workDir), uuid+".jsonl")
+	for _, r := range readSessionUsage(fsys, path) {
+		totals.Add(r, p)
+	}
+	return totals
+}
+
+// ReadUsageSince returns the usage of all assistant messages sent at or
+// after since, across every Claude session file on the filesystem.
+// A zero since returns everything.
+func ReadUsageSince(fsys tmux.FileSystem, since time.Time) []UsageRecord {
+	projectsDir, err := claudeProjectsDir(fsys)
+	if err != nil {
+		return nil
+	}
+	projectDirs, err := fsys.ReadDir(projectsDir)
+	if err != nil {
+		return nil
+	}
+
+	var records []UsageRecord
+	for _, pd := range projectDirs {
+		if !pd.IsDir {
+			continue
+		}
+		dirPath := filepath.Join(projectsDir, pd.Name)
+		entries, err := fsys.ReadDir(dirPath)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			// Files last written before since can't hold newer messages
+			if e.IsDir || !strings.HasSuffix(e.Name, ".jsonl") || e.ModTime.Before(since) {
+				continue
+			}
+			uuid := strings.TrimSuffix(e.Name, ".jsonl")
+			for _, r := range readSessionUsage(fsys, filepath.Join(dirPath, e.Name)) {
+				if r.Time.Before(since) {
+					continue
+				}
+				if r.SessionUUID == "" {
+					r.SessionUUID = uuid
+				}
+				records = append(records, r)
+			}
+		}
+	}
+	return records
+}
+
+// FormatCost formats a USD amount for display, e.g. "$1.23".
+func FormatCost(usd float64) string {
+	if usd >= 100 {
+		return fmt.Sprintf("$%.0f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// FormatTokens formats a token count compactly, e.g. "950", "12k", "3.4M".
+func FormatTokens(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 1_000_000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	}
+}
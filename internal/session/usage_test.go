@@ -0,0 +1,73 @@
+package session
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestPricingCost(t *testing.T) {
+	u := TokenUsage{Input: 1_000_000, Output: 1_000_000}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"claude-opus-4-1-20250805", 90},
+		{"claude-opus-4-5-20251101", 30},
+		{"claude-sonnet-4-5-20250929", 18},
+		{"<synthetic>", 0},
+	}
+	for _, tt := range tests {
+		if got := defaultPricing.Cost(tt.model, u); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Cost(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestReadUsageSince(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "projects", "-src-foo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// msg_a is split over two lines (one per content block) and must be
+	// counted once; msg_old predates the cutoff.
+	jsonl := `{"type":"user","cwd":"/src/foo","timestamp":"2026-01-02T10:00:00Z","message":{"content":"hi"}}
+{"type":"assistant","cwd":"/src/foo","sessionId":"uuid-1","timestamp":"2026-01-01T09:00:00Z","message":{"id":"msg_old","model":"claude-sonnet-4-5","usage":{"input_tokens":7,"output_tokens":7}}}
+{"type":"assistant","cwd":"/src/foo","sessionId":"uuid-1","timestamp":"2026-01-02T10:00:01Z","message":{"id":"msg_a","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":1,"cache_read_input_tokens":100}}}
+{"type":"assistant","cwd":"/src/foo","sessionId":"uuid-1","timestamp":"2026-01-02T10:00:02Z","message":{"id":"msg_a","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":20,"cache_read_input_tokens":100}}}
+{"type":"assistant","cwd":"/src/foo","sessionId":"uuid-1","timestamp":"2026-01-02T10:01:00Z","message":{"id":"msg_b","model":"claude-sonnet-4-5","usage":{"input_tokens":5,"output_tokens":5,"cache_creation_input_tokens":50}}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "uuid-1.jsonl"), []byte(jsonl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	records := ReadUsageSince(&tmux.LocalExecutor{}, since)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	var totals UsageTotals
+	for _, r := range records {
+		if r.SessionUUID != "uuid-1" || r.CWD != "/src/foo" {
+			t.Errorf("unexpected record %+v", r)
+		}
+		totals.Add(r, defaultPricing)
+	}
+	want := TokenUsage{Input: 15, Output: 25, CacheWrite: 50, CacheRead: 100}
+	if totals.TokenUsage != want {
+		t.Errorf("totals = %+v, want %+v", totals.TokenUsage, want)
+	}
+
+	all := SessionUsage(&tmux.LocalExecutor{}, "/src/foo", "uuid-1", defaultPricing)
+	if all.Messages != 3 {
+		t.Errorf("session messages = %d, want 3", all.Messages)
+	}
+}
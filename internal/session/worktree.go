@@ -0,0 +1,75 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/simon/crabctl/internal/state"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+// ErrWorktreeDirty is returned when removing a worktree with uncommitted
+// changes.
+var ErrWorktreeDirty = errors.New("worktree has uncommitted changes")
+
+// git runs git in dir on ex's host and returns its trimmed output.
+func git(ex tmux.Executor, dir string, args ...string) (string, error) {
+	out, err := ex.RunCommand(dir, "git", args...)
+	return strings.TrimSpace(out), err
+}
+
+// RepoRoot returns the top directory of the git repo containing dir on
+// ex's host, or dir itself when it isn't in one.
+func RepoRoot(ex tmux.Executor, dir string) string {
+	if top, err := git(ex, dir, "rev-parse", "--show-toplevel"); err == nil && top != "" {
+		return top
+	}
+	return dir
+}
+
+// CreateWorktree adds a worktree of the repo containing dir at
+// <root>/<repo>/<name>, checking out branch or creating it from HEAD.
+// Returns the worktree and the directory matching dir inside it.
+func CreateWorktree(ex tmux.Executor, dir, root, name, branch string) (state.Worktree, string, error) {
+	top, err := git(ex, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return state.Worktree{}, "", fmt.Errorf("%s is not in a git repository: %w", dir, err)
+	}
+	prefix, _ := git(ex, dir, "rev-parse", "--show-prefix")
+
+	root, err = ExpandWorkDir(ex, root)
+	if err != nil {
+		return state.Worktree{}, "", err
+	}
+	wt := state.Worktree{
+		Host:    ex.HostName(),
+		RepoDir: top,
+		Path:    filepath.Join(root, filepath.Base(top), name),
+		Branch:  branch,
+	}
+
+	args := []string{"worktree", "add", wt.Path, branch}
+	if _, err := git(ex, top, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		args = []string{"worktree", "add", "-b", branch, wt.Path}
+	}
+	if _, err := git(ex, top, args...); err != nil {
+		return state.Worktree{}, "", err
+	}
+	return wt, filepath.Join(wt.Path, prefix), nil
+}
+
+// RemoveWorktree deletes a worktree created by CreateWorktree, refusing
+// when it has uncommitted changes. The branch is kept.
+func RemoveWorktree(ex tmux.Executor, wt state.Worktree) error {
+	status, err := git(ex, wt.Path, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return ErrWorktreeDirty
+	}
+	_, err = git(ex, wt.RepoDir, "worktree", "remove", wt.Path)
+	return err
+}
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+func TestWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	ex := &tmux.LocalExecutor{}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if _, err := ex.RunCommand(repo, "git", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub := filepath.Join(repo, "pkg")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := RepoRoot(ex, sub); got != repo {
+		t.Errorf("RepoRoot(%s) = %s, want %s", sub, got, repo)
+	}
+	root := t.TempDir()
+	if got := RepoRoot(ex, root); got != root {
+		t.Errorf("RepoRoot outside a repo = %s, want %s", got, root)
+	}
+	wt, dir, err := CreateWorktree(ex, sub, root, "fixer", "fix-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := filepath.Join(root, filepath.Base(repo), "fixer")
+	if wt.Path != wantPath || wt.Branch != "fix-tests" {
+		t.Errorf("worktree = %+v, want path %s", wt, wantPath)
+	}
+	// pkg/ is empty so it isn't in the worktree, but the start dir still maps to it
+	if dir != filepath.Join(wantPath, "pkg") {
+		t.Errorf("start dir = %s", dir)
+	}
+
+	if err := os.WriteFile(filepath.Join(wt.Path, "new.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorktree(ex, wt); !errors.Is(err, ErrWorktreeDirty) {
+		t.Fatalf("remove dirty worktree: err = %v, want ErrWorktreeDirty", err)
+	}
+	os.Remove(filepath.Join(wt.Path, "new.txt"))
+	if err := RemoveWorktree(ex, wt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wt.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists: %v", err)
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"database/sql"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
+
+	"github.com/simon/crabctl/internal/config"
 )
 
 const schema = `
 CREATE TABLE IF NOT EXISTS sessions (
-    name         TEXT PRIMARY KEY,
+    name         TEXT NOT NULL DEFAULT '',
+    host         TEXT NOT NULL DEFAULT '',
     autoforward  INTEGER NOT NULL DEFAULT 0,
     killed       INTEGER NOT NULL DEFAULT 0,
     session_file TEXT NOT NULL DEFAULT '',
@@ -19,8 +23,65 @@ CREATE TABLE IF NOT EXISTS sessions (
     first_msg    TEXT NOT NULL DEFAULT '',
     last_send    TIMESTAMP,
     created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, host)
+);
+
+CREATE TABLE IF NOT EXISTS hook_events (
+    name         TEXT PRIMARY KEY,
+    event        TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL DEFAULT '',
+    tool         TEXT NOT NULL DEFAULT '',
+    detail       TEXT NOT NULL DEFAULT '',
+    session_id   TEXT NOT NULL DEFAULT '',
+    cwd          TEXT NOT NULL DEFAULT '',
     updated_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
+
+CREATE TABLE IF NOT EXISTS worktrees (
+    name         TEXT NOT NULL DEFAULT '',
+    host         TEXT NOT NULL DEFAULT '',
+    repo_dir     TEXT NOT NULL DEFAULT '',
+    path         TEXT NOT NULL DEFAULT '',
+    branch       TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, host)
+);
+
+CREATE TABLE IF NOT EXISTS message_queue (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    name         TEXT NOT NULL DEFAULT '',
+    host         TEXT NOT NULL DEFAULT '',
+    text         TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS policy_decisions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    name         TEXT NOT NULL DEFAULT '',
+    host         TEXT NOT NULL DEFAULT '',
+    tool         TEXT NOT NULL DEFAULT '',
+    argument     TEXT NOT NULL DEFAULT '',
+    action       TEXT NOT NULL DEFAULT '',
+    rule         TEXT NOT NULL DEFAULT '',
+    error        TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    name         TEXT NOT NULL DEFAULT '',
+    host         TEXT NOT NULL DEFAULT '',
+    kind         TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL DEFAULT '',
+    previous     TEXT NOT NULL DEFAULT '',
+    detail       TEXT NOT NULL DEFAULT '',
+    work_dir     TEXT NOT NULL DEFAULT '',
+    repo_dir     TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS events_created_at ON events (created_at);
 `
 
 // Store wraps a SQLite database for persistent session state.
@@ -28,11 +89,12 @@ type Store struct {
 	db *sql.DB
 }
 
-// Open creates or opens the state database at $XDG_STATE_HOME/crabctl/state.db.
-func Open() (*Store, error) {
+// Dir returns the crabctl state directory, $XDG_STATE_HOME/crabctl,
+// creating it if needed.
+func Dir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	stateHome := os.Getenv("XDG_STATE_HOME")
@@ -41,6 +103,15 @@ func Open() (*Store, error) {
 	}
 	dir := filepath.Join(stateHome, "crabctl")
 	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Open creates or opens the state database at $XDG_STATE_HOME/crabctl/state.db.
+func Open() (*Store, error) {
+	dir, err := Dir()
+	if err != nil {
 		return nil, err
 	}
 
@@ -66,37 +137,105 @@ func Open() (*Store, error) {
 		"ALTER TABLE sessions ADD COLUMN work_dir TEXT NOT NULL DEFAULT ''",
 		"ALTER TABLE sessions ADD COLUMN first_msg TEXT NOT NULL DEFAULT ''",
 		"ALTER TABLE sessions ADD COLUMN killed_at TIMESTAMP",
+		"ALTER TABLE sessions ADD COLUMN host TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE sessions ADD COLUMN claude_bin TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE sessions ADD COLUMN claude_flags TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE sessions ADD COLUMN af_message TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE sessions ADD COLUMN af_delay_ms INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE sessions ADD COLUMN af_max INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE sessions ADD COLUMN af_stop_on_done INTEGER",
+		"ALTER TABLE sessions ADD COLUMN muted INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE events ADD COLUMN repo_dir TEXT NOT NULL DEFAULT ''",
 	} {
 		db.Exec(m) //nolint:errcheck
 	}
+	if err := migrateSessions(db); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return &Store{db: db}, nil
 }
 
+// sessionColumns are the columns of the sessions table, once every
+// migration has run.
+const sessionColumns = `name, host, autoforward, killed, session_file, work_dir, first_msg,
+	last_send, created_at, updated_at, killed_at, claude_bin, claude_flags,
+	af_message, af_delay_ms, af_max, af_stop_on_done, muted`
+
+// migrateSessions rebuilds a sessions table keyed by name only, from before
+// sessions of the same name on different hosts were told apart.
+func migrateSessions(db *sql.DB) error {
+	var ddl string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'sessions'`).Scan(&ddl); err != nil {
+		return err
+	}
+	if strings.Contains(ddl, "PRIMARY KEY (name, host)") {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	for _, stmt := range []string{
+		"ALTER TABLE sessions RENAME TO sessions_old",
+		`CREATE TABLE sessions (
+    name            TEXT NOT NULL DEFAULT '',
+    host            TEXT NOT NULL DEFAULT '',
+    autoforward     INTEGER NOT NULL DEFAULT 0,
+    killed          INTEGER NOT NULL DEFAULT 0,
+    session_file    TEXT NOT NULL DEFAULT '',
+    work_dir        TEXT NOT NULL DEFAULT '',
+    first_msg       TEXT NOT NULL DEFAULT '',
+    last_send       TIMESTAMP,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    killed_at       TIMESTAMP,
+    claude_bin      TEXT NOT NULL DEFAULT '',
+    claude_flags    TEXT NOT NULL DEFAULT '',
+    af_message      TEXT NOT NULL DEFAULT '',
+    af_delay_ms     INTEGER NOT NULL DEFAULT 0,
+    af_max          INTEGER NOT NULL DEFAULT 0,
+    af_stop_on_done INTEGER,
+    muted           INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (name, host)
+)`,
+		"INSERT INTO sessions (" + sessionColumns + ") SELECT " + sessionColumns + " FROM sessions_old",
+		"DROP TABLE sessions_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // Close closes the database.
 func (s *Store) Close() error {
 	return s.db.Close()
 }
 
 // SetAutoForward enables or disables autoforward for a session.
-func (s *Store) SetAutoForward(name string, enabled bool) error {
+func (s *Store) SetAutoForward(name, host string, enabled bool) error {
 	val := 0
 	if enabled {
 		val = 1
 	}
 	_, err := s.db.Exec(`
-		INSERT INTO sessions (name, autoforward, updated_at)
-		VALUES (?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(name) DO UPDATE SET
+		INSERT INTO sessions (name, host, autoforward, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
 			autoforward = excluded.autoforward,
 			updated_at = CURRENT_TIMESTAMP
-	`, name, val)
+	`, name, host, val)
 	return err
 }
 
-// LoadAllAutoForward returns a map of session names that have autoforward enabled.
+// LoadAllAutoForward returns the sessions that have autoforward enabled,
+// keyed by QueueKey.
 func (s *Store) LoadAllAutoForward() (map[string]bool, error) {
-	rows, err := s.db.Query("SELECT name FROM sessions WHERE autoforward = 1")
+	rows, err := s.db.Query("SELECT name, host FROM sessions WHERE autoforward = 1")
 	if err != nil {
 		return nil, err
 	}
@@ -104,61 +243,173 @@ func (s *Store) LoadAllAutoForward() (map[string]bool, error) {
 
 	result := make(map[string]bool)
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var name, host string
+		if err := rows.Scan(&name, &host); err != nil {
 			return nil, err
 		}
-		result[name] = true
+		result[QueueKey(host, name)] = true
+	}
+	return result, rows.Err()
+}
+
+// SetMuted mutes or unmutes notifications for a session.
+func (s *Store) SetMuted(name, host string, muted bool) error {
+	val := 0
+	if muted {
+		val = 1
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (name, host, muted, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
+			muted = excluded.muted,
+			updated_at = CURRENT_TIMESTAMP
+	`, name, host, val)
+	return err
+}
+
+// LoadMuted returns the sessions whose notifications are muted, keyed by
+// QueueKey.
+func (s *Store) LoadMuted() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT name, host FROM sessions WHERE muted = 1")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]bool)
+	for rows.Next() {
+		var name, host string
+		if err := rows.Scan(&name, &host); err != nil {
+			return nil, err
+		}
+		result[QueueKey(host, name)] = true
+	}
+	return result, rows.Err()
+}
+
+// SetAutoForwardPolicy replaces a session's autoforward policy. Zero
+// fields fall back to the configured defaults.
+func (s *Store) SetAutoForwardPolicy(name, host string, p config.AutoForwardConfig) error {
+	var stop sql.NullBool
+	if p.StopOnTaskDone != nil {
+		stop = sql.NullBool{Bool: *p.StopOnTaskDone, Valid: true}
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (name, host, af_message, af_delay_ms, af_max, af_stop_on_done, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
+			af_message = excluded.af_message,
+			af_delay_ms = excluded.af_delay_ms,
+			af_max = excluded.af_max,
+			af_stop_on_done = excluded.af_stop_on_done,
+			updated_at = CURRENT_TIMESTAMP
+	`, name, host, p.Message, p.Delay.Milliseconds(), p.Max, stop)
+	return err
+}
+
+// LoadAutoForwardPolicies returns the autoforward policies of sessions that
+// set one, keyed by QueueKey.
+func (s *Store) LoadAutoForwardPolicies() (map[string]config.AutoForwardConfig, error) {
+	rows, err := s.db.Query(`
+		SELECT name, host, af_message, af_delay_ms, af_max, af_stop_on_done FROM sessions
+		WHERE af_message != '' OR af_delay_ms != 0 OR af_max != 0 OR af_stop_on_done IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]config.AutoForwardConfig)
+	for rows.Next() {
+		var (
+			name    string
+			host    string
+			p       config.AutoForwardConfig
+			delayMs int64
+			stop    sql.NullBool
+		)
+		if err := rows.Scan(&name, &host, &p.Message, &delayMs, &p.Max, &stop); err != nil {
+			return nil, err
+		}
+		p.Delay = time.Duration(delayMs) * time.Millisecond
+		if stop.Valid {
+			p.StopOnTaskDone = &stop.Bool
+		}
+		result[QueueKey(host, name)] = p
 	}
 	return result, rows.Err()
 }
 
 // SaveSessionUUID persists the Claude session UUID for an active session.
 // Called when a UUID is first resolved so it survives accidental kills.
-func (s *Store) SaveSessionUUID(name, sessionUUID, workDir, firstMsg string) error {
+// host is the executor nickname the session runs on (empty for local).
+func (s *Store) SaveSessionUUID(name, host, sessionUUID, workDir, firstMsg string) error {
 	_, err := s.db.Exec(`
-		INSERT INTO sessions (name, session_file, work_dir, first_msg, updated_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(name) DO UPDATE SET
+		INSERT INTO sessions (name, host, session_file, work_dir, first_msg, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
 			session_file = excluded.session_file,
 			work_dir = excluded.work_dir,
 			first_msg = excluded.first_msg,
 			updated_at = CURRENT_TIMESTAMP
-	`, name, sessionUUID, workDir, firstMsg)
+	`, name, host, sessionUUID, workDir, firstMsg)
 	return err
 }
 
-// MarkKilled records a session as killed with its Claude session UUID, workdir, and first message.
-func (s *Store) MarkKilled(name, sessionUUID, workDir, firstMsg string) error {
+// MarkKilled records a session as killed with its host, Claude session UUID, workdir, and first message.
+func (s *Store) MarkKilled(name, host, sessionUUID, workDir, firstMsg string) error {
 	_, err := s.db.Exec(`
-		INSERT INTO sessions (name, killed, session_file, work_dir, first_msg, killed_at, updated_at)
-		VALUES (?, 1, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-		ON CONFLICT(name) DO UPDATE SET
+		INSERT INTO sessions (name, host, killed, session_file, work_dir, first_msg, killed_at, updated_at)
+		VALUES (?, ?, 1, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
 			killed = 1,
 			session_file = excluded.session_file,
 			work_dir = excluded.work_dir,
 			first_msg = excluded.first_msg,
 			killed_at = CURRENT_TIMESTAMP,
 			updated_at = CURRENT_TIMESTAMP
-	`, name, sessionUUID, workDir, firstMsg)
+	`, name, host, sessionUUID, workDir, firstMsg)
+	return err
+}
+
+// SaveClaudeFlags persists how claude was started in a session, so it can
+// be resumed with the same flags after the tmux session is gone.
+func (s *Store) SaveClaudeFlags(name, host, claudeBin, claudeFlags string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (name, host, claude_bin, claude_flags, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
+			claude_bin = excluded.claude_bin,
+			claude_flags = excluded.claude_flags,
+			updated_at = CURRENT_TIMESTAMP
+	`, name, host, claudeBin, claudeFlags)
 	return err
 }
 
 // PastSession represents a session that can be resumed.
 type PastSession struct {
 	Name        string
+	Host        string // executor nickname, empty for local
 	SessionUUID string
 	WorkDir     string
 	FirstMsg    string
 	LastSeen    time.Time
-	Killed      bool // true if explicitly killed via crabctl
+	Killed      bool   // true if explicitly killed via crabctl
+	ClaudeBin   string // claude binary it was started with, if not the default
+	ClaudeFlags string // claude flags it was started with
 }
 
 // ListResumable returns all sessions with a UUID, ordered by most recent first.
 // Includes both explicitly killed sessions and ones that disappeared (Ctrl+C, crash).
+// A nil store has none.
 func (s *Store) ListResumable(limit int) ([]PastSession, error) {
+	if s == nil {
+		return nil, nil
+	}
 	rows, err := s.db.Query(`
-		SELECT name, session_file, work_dir, first_msg, killed,
+		SELECT name, host, session_file, work_dir, first_msg, killed,
+			claude_bin, claude_flags,
 			COALESCE(killed_at, updated_at) AS last_seen
 		FROM sessions
 		WHERE session_file != ''
@@ -175,7 +426,7 @@ func (s *Store) ListResumable(limit int) ([]PastSession, error) {
 		var ps PastSession
 		var lastSeen string
 		var killed int
-		if err := rows.Scan(&ps.Name, &ps.SessionUUID, &ps.WorkDir, &ps.FirstMsg, &killed, &lastSeen); err != nil {
+		if err := rows.Scan(&ps.Name, &ps.Host, &ps.SessionUUID, &ps.WorkDir, &ps.FirstMsg, &killed, &ps.ClaudeBin, &ps.ClaudeFlags, &lastSeen); err != nil {
 			return nil, err
 		}
 		ps.Killed = killed == 1
@@ -184,3 +435,325 @@ func (s *Store) ListResumable(limit int) ([]PastSession, error) {
 	}
 	return result, rows.Err()
 }
+
+// GetSession returns what's saved for a session with a UUID, if anything.
+// LastSeen is when the row was last written. A nil store has none.
+func (s *Store) GetSession(name, host string) (PastSession, bool) {
+	ps := PastSession{Name: name, Host: host}
+	if s == nil {
+		return ps, false
+	}
+	var killed int
+	err := s.db.QueryRow(`
+		SELECT session_file, work_dir, first_msg, killed, claude_bin, claude_flags, updated_at
+		FROM sessions
+		WHERE name = ? AND host = ? AND session_file != ''
+	`, name, host).Scan(&ps.SessionUUID, &ps.WorkDir, &ps.FirstMsg, &killed, &ps.ClaudeBin, &ps.ClaudeFlags, &ps.LastSeen)
+	ps.Killed = killed == 1
+	return ps, err == nil
+}
+
+// HookEvent is the latest Claude Code hook event reported for a tmux session.
+type HookEvent struct {
+	Name      string // tmux session name
+	Event     string // hook event name, e.g. "PreToolUse"
+	Status    string // session status implied by the event, e.g. "running"
+	Tool      string // tool name for tool events
+	Detail    string // human-readable detail, e.g. "Bash(go test ./...)"
+	SessionID string // Claude session UUID
+	CWD       string
+	UpdatedAt time.Time
+}
+
+// RecordHookEvent stores the latest hook event for a tmux session.
+func (s *Store) RecordHookEvent(ev HookEvent) error {
+	_, err := s.db.Exec(`
+		INSERT INTO hook_events (name, event, status, tool, detail, session_id, cwd, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET
+			event = excluded.event,
+			status = excluded.status,
+			tool = excluded.tool,
+			detail = excluded.detail,
+			session_id = excluded.session_id,
+			cwd = excluded.cwd,
+			updated_at = CURRENT_TIMESTAMP
+	`, ev.Name, ev.Event, ev.Status, ev.Tool, ev.Detail, ev.SessionID, ev.CWD)
+	return err
+}
+
+// LoadHookEvents returns the latest hook event per tmux session name.
+// A nil store has none.
+func (s *Store) LoadHookEvents() (map[string]HookEvent, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT name, event, status, tool, detail, session_id, cwd, updated_at
+		FROM hook_events
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]HookEvent)
+	for rows.Next() {
+		var ev HookEvent
+		if err := rows.Scan(&ev.Name, &ev.Event, &ev.Status, &ev.Tool, &ev.Detail, &ev.SessionID, &ev.CWD, &ev.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result[ev.Name] = ev
+	}
+	return result, rows.Err()
+}
+
+// Worktree is a git worktree crabctl created for a session.
+type Worktree struct {
+	Name    string // tmux session name
+	Host    string
+	RepoDir string // main checkout the worktree belongs to
+	Path    string
+	Branch  string
+}
+
+// SaveWorktree records the worktree created for a session.
+func (s *Store) SaveWorktree(wt Worktree) error {
+	_, err := s.db.Exec(`
+		INSERT INTO worktrees (name, host, repo_dir, path, branch, created_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, host) DO UPDATE SET
+			repo_dir = excluded.repo_dir,
+			path = excluded.path,
+			branch = excluded.branch,
+			created_at = CURRENT_TIMESTAMP
+	`, wt.Name, wt.Host, wt.RepoDir, wt.Path, wt.Branch)
+	return err
+}
+
+// GetWorktree returns the worktree recorded for a session, if any.
+func (s *Store) GetWorktree(name, host string) (Worktree, bool) {
+	wt := Worktree{Name: name, Host: host}
+	err := s.db.QueryRow(`
+		SELECT repo_dir, path, branch FROM worktrees WHERE name = ? AND host = ?
+	`, name, host).Scan(&wt.RepoDir, &wt.Path, &wt.Branch)
+	return wt, err == nil
+}
+
+// DeleteWorktree forgets the worktree recorded for a session.
+func (s *Store) DeleteWorktree(name, host string) error {
+	_, err := s.db.Exec("DELETE FROM worktrees WHERE name = ? AND host = ?", name, host)
+	return err
+}
+
+// QueuedMessage is a message waiting to be sent to a session once it's idle.
+type QueuedMessage struct {
+	ID        int64
+	Name      string // tmux session name
+	Host      string
+	Text      string
+	CreatedAt time.Time
+}
+
+// QueueKey identifies a session in maps of per-session state, such as
+// QueueDepths: the tmux session name, prefixed with "host:" for remote
+// sessions.
+func QueueKey(host, name string) string {
+	if host == "" {
+		return name
+	}
+	return host + ":" + name
+}
+
+// EnqueueMessage appends a message to a session's queue and returns the
+// number of messages now queued for it.
+func (s *Store) EnqueueMessage(name, host, text string) (int, error) {
+	if _, err := s.db.Exec(`
+		INSERT INTO message_queue (name, host, text) VALUES (?, ?, ?)
+	`, name, host, text); err != nil {
+		return 0, err
+	}
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM message_queue WHERE name = ? AND host = ?
+	`, name, host).Scan(&n)
+	return n, err
+}
+
+// NextQueuedMessage returns the oldest message queued for a session.
+func (s *Store) NextQueuedMessage(name, host string) (QueuedMessage, bool) {
+	m := QueuedMessage{Name: name, Host: host}
+	err := s.db.QueryRow(`
+		SELECT id, text, created_at FROM message_queue
+		WHERE name = ? AND host = ?
+		ORDER BY id LIMIT 1
+	`, name, host).Scan(&m.ID, &m.Text, &m.CreatedAt)
+	return m, err == nil
+}
+
+// DeleteQueuedMessage removes a delivered message from its queue.
+func (s *Store) DeleteQueuedMessage(id int64) error {
+	_, err := s.db.Exec("DELETE FROM message_queue WHERE id = ?", id)
+	return err
+}
+
+// ClearQueue drops every message queued for a session.
+func (s *Store) ClearQueue(name, host string) error {
+	_, err := s.db.Exec("DELETE FROM message_queue WHERE name = ? AND host = ?", name, host)
+	return err
+}
+
+// QueueDepths returns the number of queued messages per session, keyed by
+// QueueKey.
+func (s *Store) QueueDepths() (map[string]int, error) {
+	rows, err := s.db.Query(`
+		SELECT name, host, COUNT(*) FROM message_queue GROUP BY name, host
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]int)
+	for rows.Next() {
+		var name, host string
+		var n int
+		if err := rows.Scan(&name, &host, &n); err != nil {
+			return nil, err
+		}
+		result[QueueKey(host, name)] = n
+	}
+	return result, rows.Err()
+}
+
+// PolicyDecision is a permission prompt answered by the auto-approval policy.
+type PolicyDecision struct {
+	Name      string // tmux session name
+	Host      string
+	Tool      string
+	Argument  string
+	Action    string // "allow" or "deny"
+	Rule      string // policy rule that matched
+	Error     string // set when the answer couldn't be sent
+	CreatedAt time.Time
+}
+
+// RecordPolicyDecision appends a decision to the policy log.
+func (s *Store) RecordPolicyDecision(d PolicyDecision) error {
+	_, err := s.db.Exec(`
+		INSERT INTO policy_decisions (name, host, tool, argument, action, rule, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, d.Name, d.Host, d.Tool, d.Argument, d.Action, d.Rule, d.Error)
+	return err
+}
+
+// ListPolicyDecisions returns the most recent policy decisions, newest first.
+func (s *Store) ListPolicyDecisions(limit int) ([]PolicyDecision, error) {
+	rows, err := s.db.Query(`
+		SELECT name, host, tool, argument, action, rule, error, created_at
+		FROM policy_decisions
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PolicyDecision
+	for rows.Next() {
+		var d PolicyDecision
+		if err := rows.Scan(&d.Name, &d.Host, &d.Tool, &d.Argument, &d.Action, &d.Rule, &d.Error, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// Event kinds in the session history.
+const (
+	EventNew         = "new"         // session created; Detail is the first message
+	EventResume      = "resume"      // session resumed; Detail is the Claude session UUID
+	EventStatus      = "status"      // status changed, or the first status of a new session
+	EventSend        = "send"        // text typed into the session
+	EventAutoForward = "autoforward" // autoforward message sent
+	EventKill        = "kill"        // killed through crabctl
+	EventEnd         = "end"         // session gone from its host, killed or exited
+)
+
+// timeFormat is how event times are stored: UTC, as CURRENT_TIMESTAMP
+// writes them, so they compare as strings.
+const timeFormat = "2006-01-02 15:04:05"
+
+// Event is an entry in the session history.
+type Event struct {
+	ID       int64
+	Name     string // tmux session name
+	Host     string
+	Kind     string
+	Status   string // status entered, for status events
+	Previous string // status left, for status events; empty for a new session
+	Detail   string
+	WorkDir  string
+	RepoDir  string // git repo WorkDir belongs to, for grouping by repo
+	Time     time.Time
+}
+
+// RecordEvent appends an event to the session history, at ev.Time or now.
+// A nil store records nothing, so callers without a database needn't check.
+func (s *Store) RecordEvent(ev Event) error {
+	if s == nil {
+		return nil
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO events (name, host, kind, status, previous, detail, work_dir, repo_dir, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ev.Name, ev.Host, ev.Kind, ev.Status, ev.Previous, ev.Detail, ev.WorkDir, ev.RepoDir, ev.Time.UTC().Format(timeFormat))
+	return err
+}
+
+// ListEvents returns the events since the given time, oldest first: those
+// of one session, or of every session when name is empty.
+func (s *Store) ListEvents(name, host string, since time.Time) ([]Event, error) {
+	if s == nil {
+		return nil, nil
+	}
+	query := `
+		SELECT id, name, host, kind, status, previous, detail, work_dir, repo_dir, created_at
+		FROM events
+		WHERE created_at >= ?`
+	args := []any{since.UTC().Format(timeFormat)}
+	if name != "" {
+		query += " AND name = ? AND host = ?"
+		args = append(args, name, host)
+	}
+	rows, err := s.db.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Event
+	for rows.Next() {
+		var ev Event
+		if err := rows.Scan(&ev.ID, &ev.Name, &ev.Host, &ev.Kind, &ev.Status, &ev.Previous, &ev.Detail, &ev.WorkDir, &ev.RepoDir, &ev.Time); err != nil {
+			return nil, err
+		}
+		result = append(result, ev)
+	}
+	return result, rows.Err()
+}
+
+// PruneEvents deletes the events older than before and returns how many
+// went.
+func (s *Store) PruneEvents(before time.Time) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM events WHERE created_at < ?", before.UTC().Format(timeFormat))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package tmux
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Session environment variables recording how claude was started.
+const (
+	FlagsEnv     = "CRABCTL_FLAGS"      // shell-quoted claude arguments
+	ClaudeBinEnv = "CRABCTL_CLAUDE_BIN" // claude binary, when not "claude"
+)
+
+// safeArg matches arguments that need no quoting in a shell command.
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// ClaudeCommand returns the shell command that starts claude with args,
+// unsetting CLAUDECODE to allow nesting.
+func ClaudeCommand(claudeBin string, args []string) string {
+	if claudeBin == "" {
+		claudeBin = "claude"
+	}
+	cmd := "unset CLAUDECODE; " + claudeBin
+	if len(args) > 0 {
+		cmd += " " + QuoteArgs(args)
+	}
+	return cmd
+}
+
+// QuoteArgs joins args into a shell command line, quoting where needed.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if safeArg.MatchString(a) {
+			quoted[i] = a
+		} else {
+			quoted[i] = shellQuote(a)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// SplitArgs splits a command line produced by QuoteArgs back into its
+// arguments. It understands single and double quotes and backslashes.
+func SplitArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
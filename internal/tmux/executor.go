@@ -1,15 +1,23 @@
 package tmux
 
 // Executor abstracts tmux operations so they can run locally or over SSH.
+// It also exposes the host's filesystem for reading Claude session files.
 type Executor interface {
+	FileSystem
 	HostName() string
 	SessionPrefix() string
 	ListSessions() ([]SessionInfo, error)
 	CapturePaneOutput(fullName string, lines int) (string, error)
-	NewSession(name, workDir string, claudeArgs []string) error
+	CapturePaneRaw(fullName string, lines int) (string, error)
+	NewSession(name, workDir, claudeBin string, claudeArgs []string) error
+	GetSessionEnv(fullName, key string) string
 	SendKeys(fullName, text string) error
+	SendKey(fullName, key string) error
 	KillSession(fullName string) error
 	HasSession(fullName string) bool
 	GetPanePath(fullName string) string
 	AttachSession(fullName string) error
+	// RunCommand runs a program in dir on the executor's host and returns
+	// its output. Errors include the program's stderr.
+	RunCommand(dir, name string, args ...string) (string, error)
 }
@@ -0,0 +1,33 @@
+package tmux
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// FileInfo describes a file on the executor's host.
+type FileInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// FileSystem gives read-only access to files on the host an executor runs
+// on, so Claude's session files can be found for remote crabs too.
+type FileSystem interface {
+	HomeDir() (string, error)
+	ReadDir(path string) ([]FileInfo, error)
+	Stat(path string) (FileInfo, error)
+	OpenFile(path string) (io.ReadCloser, error)
+}
+
+func toFileInfo(info os.FileInfo) FileInfo {
+	return FileInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
@@ -2,6 +2,9 @@ package tmux
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -21,14 +24,26 @@ func (l *LocalExecutor) CapturePaneOutput(fullName string, lines int) (string, e
 	return CapturePaneOutput(fullName, lines)
 }
 
-func (l *LocalExecutor) NewSession(name, workDir string, claudeArgs []string) error {
-	return NewSession(name, workDir, claudeArgs)
+func (l *LocalExecutor) CapturePaneRaw(fullName string, lines int) (string, error) {
+	return CapturePaneRaw(fullName, lines)
+}
+
+func (l *LocalExecutor) NewSession(name, workDir, claudeBin string, claudeArgs []string) error {
+	return NewSession(name, workDir, claudeBin, claudeArgs)
+}
+
+func (l *LocalExecutor) GetSessionEnv(fullName, key string) string {
+	return GetSessionEnv(fullName, key)
 }
 
 func (l *LocalExecutor) SendKeys(fullName, text string) error {
 	return SendKeys(fullName, text)
 }
 
+func (l *LocalExecutor) SendKey(fullName, key string) error {
+	return SendKey(fullName, key)
+}
+
 func (l *LocalExecutor) KillSession(fullName string) error {
 	return KillSession(fullName)
 }
@@ -45,6 +60,53 @@ func (l *LocalExecutor) AttachSession(fullName string) error {
 	return RunAttachSession(fullName)
 }
 
+func (l *LocalExecutor) RunCommand(dir, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(out), fmt.Errorf("%s: %s", name, msg)
+		}
+		return string(out), err
+	}
+	return string(out), nil
+}
+
+func (l *LocalExecutor) HomeDir() (string, error) {
+	return os.UserHomeDir()
+}
+
+func (l *LocalExecutor) ReadDir(path string) ([]FileInfo, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, toFileInfo(info))
+	}
+	return infos, nil
+}
+
+func (l *LocalExecutor) Stat(path string) (FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return toFileInfo(info), nil
+}
+
+func (l *LocalExecutor) OpenFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
 // listSessionsWithPrefix lists tmux sessions with the given prefix.
 func listSessionsWithPrefix(prefix string) ([]SessionInfo, error) {
 	tmuxBin, err := FindTmux()
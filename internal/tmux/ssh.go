@@ -2,9 +2,13 @@ package tmux
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // SSHExecutor runs tmux commands on a remote host over SSH.
@@ -14,6 +18,10 @@ type SSHExecutor struct {
 	User     string
 	SSHKey   string
 	Prefix   string
+
+	homeOnce sync.Once
+	home     string
+	homeErr  error
 }
 
 func (s *SSHExecutor) HostName() string      { return s.Nickname }
@@ -43,6 +51,24 @@ func (s *SSHExecutor) run(remoteCmd string) (string, error) {
 	return string(out), nil
 }
 
+func (s *SSHExecutor) RunCommand(dir, name string, args ...string) (string, error) {
+	remoteCmd := QuoteArgs(append([]string{name}, args...))
+	if dir != "" {
+		remoteCmd = "cd " + shellQuote(dir) + " && " + remoteCmd
+	}
+	cmd := exec.Command("ssh", append(s.sshArgs(), remoteCmd)...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(out), fmt.Errorf("%s: %s", name, msg)
+		}
+		return string(out), err
+	}
+	return string(out), nil
+}
+
 func (s *SSHExecutor) ListSessions() ([]SessionInfo, error) {
 	out, err := s.run(fmt.Sprintf("tmux list-sessions -F '#{session_name}|#{session_attached}|#{session_created}' 2>/dev/null"))
 	if err != nil {
@@ -62,7 +88,15 @@ func (s *SSHExecutor) CapturePaneOutput(fullName string, lines int) (string, err
 	return cleaned, nil
 }
 
-func (s *SSHExecutor) NewSession(name, workDir string, claudeArgs []string) error {
+func (s *SSHExecutor) CapturePaneRaw(fullName string, lines int) (string, error) {
+	out, err := s.run(fmt.Sprintf("tmux capture-pane -t %s -p -e -S -%d", shellQuote(fullName), lines))
+	if err != nil {
+		return "", err
+	}
+	return stripDimText(out), nil
+}
+
+func (s *SSHExecutor) NewSession(name, workDir, claudeBin string, claudeArgs []string) error {
 	fullName := s.Prefix + name
 	cmd := fmt.Sprintf("tmux new-session -d -s %s", shellQuote(fullName))
 	if workDir != "" {
@@ -75,28 +109,46 @@ func (s *SSHExecutor) NewSession(name, workDir string, claudeArgs []string) erro
 	}
 
 	// Send claude command via send-keys to avoid quoting issues through SSH
-	claudeCmd := "unset CLAUDECODE; claude"
-	for _, a := range claudeArgs {
-		claudeCmd += " " + a
-	}
+	claudeCmd := ClaudeCommand(claudeBin, claudeArgs)
 	s.run(fmt.Sprintf("tmux send-keys -t %s -l %s", shellQuote(fullName), shellQuote(claudeCmd)))
 	s.run(fmt.Sprintf("tmux send-keys -t %s Enter", shellQuote(fullName)))
 
 	// Store claude flags
 	if len(claudeArgs) > 0 {
-		s.run(fmt.Sprintf("tmux set-environment -t %s CRABCTL_FLAGS %s",
-			shellQuote(fullName), shellQuote(strings.Join(claudeArgs, " "))))
+		s.run(fmt.Sprintf("tmux set-environment -t %s %s %s",
+			shellQuote(fullName), FlagsEnv, shellQuote(QuoteArgs(claudeArgs))))
+	}
+	if claudeBin != "" {
+		s.run(fmt.Sprintf("tmux set-environment -t %s %s %s",
+			shellQuote(fullName), ClaudeBinEnv, shellQuote(claudeBin)))
 	}
 
 	return nil
 }
 
+func (s *SSHExecutor) GetSessionEnv(fullName, key string) string {
+	out, err := s.run(fmt.Sprintf("tmux show-environment -t %s %s", shellQuote(fullName), shellQuote(key)))
+	if err != nil {
+		return ""
+	}
+	// Output is "KEY=value\n"
+	if _, v, ok := strings.Cut(strings.TrimSpace(out), "="); ok {
+		return v
+	}
+	return ""
+}
+
 func (s *SSHExecutor) SendKeys(fullName, text string) error {
 	_, err := s.run(fmt.Sprintf("tmux send-keys -t %s -l %s && tmux send-keys -t %s Enter",
 		shellQuote(fullName), shellQuote(text), shellQuote(fullName)))
 	return err
 }
 
+func (s *SSHExecutor) SendKey(fullName, key string) error {
+	_, err := s.run(fmt.Sprintf("tmux send-keys -t %s %s", shellQuote(fullName), shellQuote(key)))
+	return err
+}
+
 func (s *SSHExecutor) KillSession(fullName string) error {
 	s.run(fmt.Sprintf("tmux send-keys -t %s C-c ''", shellQuote(fullName)))
 	_, err := s.run(fmt.Sprintf("sleep 0.5 && tmux kill-session -t %s", shellQuote(fullName)))
@@ -128,6 +180,92 @@ func (s *SSHExecutor) AttachSession(fullName string) error {
 	return cmd.Run()
 }
 
+func (s *SSHExecutor) HomeDir() (string, error) {
+	s.homeOnce.Do(func() {
+		out, err := s.run(`printf '%s' "$HOME"`)
+		if err == nil && out == "" {
+			err = fmt.Errorf("empty $HOME on %s", s.Nickname)
+		}
+		s.home, s.homeErr = out, err
+	})
+	return s.home, s.homeErr
+}
+
+// findFormat prints type, size, mtime and name; the name goes last since it
+// may itself contain the separator. Requires GNU find on the remote host.
+const findFormat = `%y|%s|%T@|%f\n`
+
+func (s *SSHExecutor) ReadDir(path string) ([]FileInfo, error) {
+	out, err := s.run(fmt.Sprintf("find %s -mindepth 1 -maxdepth 1 -printf '%s'", shellQuote(path), findFormat))
+	if err != nil {
+		return nil, err
+	}
+	return parseFindOutput(out), nil
+}
+
+func (s *SSHExecutor) Stat(path string) (FileInfo, error) {
+	out, err := s.run(fmt.Sprintf("find %s -maxdepth 0 -printf '%s'", shellQuote(path), findFormat))
+	if err != nil {
+		return FileInfo{}, err
+	}
+	infos := parseFindOutput(out)
+	if len(infos) == 0 {
+		return FileInfo{}, os.ErrNotExist
+	}
+	return infos[0], nil
+}
+
+// OpenFile streams a remote file through cat. Closing the reader early
+// stops the transfer.
+func (s *SSHExecutor) OpenFile(path string) (io.ReadCloser, error) {
+	args := append(s.sshArgs(), "cat "+shellQuote(path))
+	cmd := exec.Command("ssh", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &cmdReader{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// cmdReader reads a command's stdout and reaps the process on Close.
+type cmdReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (r *cmdReader) Close() error {
+	r.ReadCloser.Close()
+	if r.cmd.ProcessState == nil {
+		r.cmd.Process.Kill() //nolint:errcheck
+	}
+	r.cmd.Wait() //nolint:errcheck
+	return nil
+}
+
+// parseFindOutput parses lines printed by find with findFormat.
+func parseFindOutput(out string) []FileInfo {
+	var infos []FileInfo
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "|", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(parts[1], 10, 64)
+		mtime, _ := strconv.ParseFloat(parts[2], 64)
+		sec := int64(mtime)
+		infos = append(infos, FileInfo{
+			Name:    parts[3],
+			Size:    size,
+			ModTime: time.Unix(sec, int64((mtime-float64(sec))*1e9)),
+			IsDir:   parts[0] == "d",
+		})
+	}
+	return infos
+}
+
 // shellQuote wraps a string in single quotes, escaping any single quotes inside.
 func shellQuote(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
@@ -1,6 +1,7 @@
 package tmux
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -60,6 +61,22 @@ func CapturePaneOutput(fullName string, lines int) (string, error) {
 	return cleaned, nil
 }
 
+// CapturePaneRaw captures the last N lines from a tmux pane, keeping ANSI
+// styling but dropping dim autocomplete ghosts like CapturePaneOutput.
+func CapturePaneRaw(fullName string, lines int) (string, error) {
+	tmux, err := FindTmux()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(tmux, "capture-pane", "-t", fullName, "-p", "-e", "-S", fmt.Sprintf("-%d", lines))
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return stripDimText(string(out)), nil
+}
+
 var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 
 // stripDimText removes text rendered with dim (SGR 2) or bright-black
@@ -129,8 +146,10 @@ func hasSGR(params, code string) bool {
 	return false
 }
 
-// NewSession creates a new detached tmux session running claude.
-func NewSession(name, workDir string, claudeArgs []string) error {
+// NewSession creates a new detached tmux session running claude, or
+// claudeBin when set. The flags are stored in the session's environment
+// (see FlagsEnv) so they can be reused when the crab is resumed.
+func NewSession(name, workDir, claudeBin string, claudeArgs []string) error {
 	tmux, err := FindTmux()
 	if err != nil {
 		return err
@@ -141,13 +160,7 @@ func NewSession(name, workDir string, claudeArgs []string) error {
 	if workDir != "" {
 		args = append(args, "-c", workDir)
 	}
-
-	// Build the claude command, unsetting CLAUDECODE to allow nesting
-	claudeCmd := "unset CLAUDECODE; claude"
-	for _, a := range claudeArgs {
-		claudeCmd += " " + a
-	}
-	args = append(args, claudeCmd)
+	args = append(args, ClaudeCommand(claudeBin, claudeArgs))
 
 	cmd := exec.Command(tmux, args...)
 	cmd.Stdout = os.Stdout
@@ -156,10 +169,14 @@ func NewSession(name, workDir string, claudeArgs []string) error {
 		return err
 	}
 
-	// Store claude flags as tmux session environment variable
+	// Store claude flags as tmux session environment variables
 	if len(claudeArgs) > 0 {
 		setEnv := exec.Command(tmux, "set-environment", "-t", fullName,
-			"CRABCTL_FLAGS", strings.Join(claudeArgs, " "))
+			FlagsEnv, QuoteArgs(claudeArgs))
+		_ = setEnv.Run()
+	}
+	if claudeBin != "" {
+		setEnv := exec.Command(tmux, "set-environment", "-t", fullName, ClaudeBinEnv, claudeBin)
 		_ = setEnv.Run()
 	}
 
@@ -234,6 +251,16 @@ func SendKeys(fullName, text string) error {
 	return cmd.Run()
 }
 
+// SendKey sends a single key to a tmux session without pressing Enter,
+// e.g. "1" to pick a menu option or "Escape". key is a tmux key name.
+func SendKey(fullName, key string) error {
+	tmux, err := FindTmux()
+	if err != nil {
+		return err
+	}
+	return exec.Command(tmux, "send-keys", "-t", fullName, key).Run()
+}
+
 // SendEnter sends just the Enter key to a session.
 func SendEnter(fullName string) {
 	tmuxBin, err := FindTmux()
@@ -294,6 +321,25 @@ func GetPanePath(fullName string) string {
 	return strings.TrimSpace(string(out))
 }
 
+// CurrentSessionName returns the name of the tmux session this process runs
+// in, or "" when not inside tmux.
+func CurrentSessionName() string {
+	pane := os.Getenv("TMUX_PANE")
+	if os.Getenv("TMUX") == "" || pane == "" {
+		return ""
+	}
+	tmuxBin, err := FindTmux()
+	if err != nil {
+		return ""
+	}
+
+	out, err := exec.Command(tmuxBin, "display-message", "-t", pane, "-p", "#{session_name}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 // GetSessionCreated returns the creation time of a tmux session.
 func GetSessionCreated(fullName string) time.Time {
 	tmuxBin, err := FindTmux()
@@ -312,3 +358,24 @@ func GetSessionCreated(fullName string) time.Time {
 	}
 	return time.Unix(epoch, 0)
 }
+
+// DisplayMessage shows msg in the status line of every client attached to
+// the local tmux server.
+func DisplayMessage(msg string) error {
+	tmuxBin, err := FindTmux()
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command(tmuxBin, "list-clients", "-F", "#{client_name}").Output()
+	if err != nil {
+		return nil // no server running, so nobody to tell
+	}
+	var errs []error
+	for _, client := range strings.Fields(string(out)) {
+		if err := exec.Command(tmuxBin, "display-message", "-c", client, msg).Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", client, err))
+		}
+	}
+	return errors.Join(errs...)
+}
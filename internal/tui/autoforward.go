@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+)
+
+// autoForwardFields are the labels of the autoforward dialog's inputs, in
+// the order ParseAutoForwardPolicy takes them.
+var autoForwardFields = []string{"Message", "Delay", "Max forwards", "Stop on TASK DONE"}
+
+// autoForwardDialog edits the autoforward policy of one session.
+type autoForwardDialog struct {
+	SessionName string
+	FullName    string
+	Host        string
+	Focus       int
+	Inputs      []textinput.Model
+	Err         string
+}
+
+// openAutoForwardDialog opens the autoforward settings of the selected
+// session, prefilled with what it sets itself. Empty fields show the
+// defaults they fall back to.
+func (m Model) openAutoForwardDialog() (tea.Model, tea.Cmd) {
+	sel := m.selectedSession()
+	if sel == nil {
+		return m, nil
+	}
+	own := m.autoForward.OwnPolicy(sel.FullName, sel.Host)
+	values := []string{own.Message, "", "", ""}
+	if own.Delay != 0 {
+		values[1] = own.Delay.String()
+	}
+	if own.Max != 0 {
+		values[2] = strconv.Itoa(own.Max)
+	}
+	if own.StopOnTaskDone != nil {
+		values[3] = yesNo(*own.StopOnTaskDone)
+	}
+	def := m.autoForward.Defaults
+	defaults := []string{def.Message, def.Delay.String(), strconv.Itoa(def.Max), yesNo(def.StopsOnTaskDone())}
+
+	d := &autoForwardDialog{SessionName: sel.Name, FullName: sel.FullName, Host: sel.Host}
+	for i := range autoForwardFields {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.CharLimit = 4096
+		ti.Width = max(20, m.width-24)
+		ti.Placeholder = defaults[i]
+		ti.SetValue(values[i])
+		d.Inputs = append(d.Inputs, ti)
+	}
+	d.Inputs[0].Focus()
+	m.afDialog = d
+	return m, nil
+}
+
+func (m Model) handleAutoForwardDialogKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.afDialog
+	switch msg.String() {
+	case "tab", "down":
+		d.focus((d.Focus + 1) % len(d.Inputs))
+		return m, nil
+	case "shift+tab", "up":
+		d.focus((d.Focus + len(d.Inputs) - 1) % len(d.Inputs))
+		return m, nil
+	case "enter":
+		return m.saveAutoForwardDialog()
+	}
+	var cmd tea.Cmd
+	d.Inputs[d.Focus], cmd = d.Inputs[d.Focus].Update(msg)
+	d.Err = ""
+	return m, cmd
+}
+
+func (d *autoForwardDialog) focus(i int) {
+	d.Inputs[d.Focus].Blur()
+	d.Focus = i
+	d.Inputs[i].Focus()
+}
+
+// saveAutoForwardDialog stores the dialog's policy and closes it, or keeps
+// it open with the validation error.
+func (m Model) saveAutoForwardDialog() (tea.Model, tea.Cmd) {
+	d := m.afDialog
+	p, err := session.ParseAutoForwardPolicy(d.Inputs[0].Value(), d.Inputs[1].Value(), d.Inputs[2].Value(), d.Inputs[3].Value())
+	if err != nil {
+		d.Err = err.Error()
+		return m, nil
+	}
+	if m.store == nil {
+		d.Err = "saving needs the state database"
+		return m, nil
+	}
+	if err := m.store.SetAutoForwardPolicy(d.FullName, d.Host, p); err != nil {
+		d.Err = err.Error()
+		return m, nil
+	}
+	m.autoForward.SetPolicy(d.FullName, d.Host, p)
+	m.afDialog = nil
+	m.notice = fmt.Sprintf("autoforward for %s: %s", d.SessionName, describeAutoForward(m.autoForward.Policy(d.FullName, d.Host)))
+	if !m.autoForward.Enabled(d.FullName, d.Host) {
+		m.notice += " (off, ctrl+a to enable)"
+	}
+	return m, nil
+}
+
+// describeAutoForward summarizes a policy without its message.
+func describeAutoForward(p config.AutoForwardConfig) string {
+	s := fmt.Sprintf("after %s, up to %d times", p.Delay, p.Max)
+	if !p.StopsOnTaskDone() {
+		s += ", even after TASK DONE"
+	}
+	return s
+}
+
+func (m Model) renderAutoForwardDialog(b *strings.Builder) {
+	d := m.afDialog
+	borderTitle := fmt.Sprintf(" ─── autoforward: %s ", d.SessionName)
+	if remaining := m.width - lipgloss.Width(borderTitle) - 2; remaining > 0 {
+		borderTitle += strings.Repeat("─", remaining)
+	}
+	b.WriteString(previewBorderStyle.Render(" " + borderTitle))
+	b.WriteString("\n\n")
+
+	for i, label := range autoForwardFields {
+		marker := "  "
+		if i == d.Focus {
+			marker = inputLabelStyle.Render("> ")
+		}
+		b.WriteString(" " + marker + headerStyle.Render(pad(label, 19)) + d.Inputs[i].View())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	status := "off"
+	if m.autoForward.Enabled(d.FullName, d.Host) && m.daemonRunning {
+		status = "on (run by crabctl daemon)"
+	} else if m.autoForward.Enabled(d.FullName, d.Host) {
+		left, limit := m.autoForward.Remaining(d.FullName, d.Host)
+		status = fmt.Sprintf("on, %d of %d forwards left", left, limit)
+	}
+	b.WriteString(previewContentStyle.Render("   Autoforward is " + status + ". Empty fields use the defaults shown."))
+	b.WriteString("\n")
+	if d.Err != "" {
+		b.WriteString(statusPermission.Render("   " + d.Err))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(previewBorderStyle.Render(" " + strings.Repeat("─", max(0, m.width-2))))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("tab next field  enter save  esc cancel"))
+	b.WriteString("\n")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+// historyLoadedMsg carries the recorded events of one session.
+type historyLoadedMsg struct {
+	FullName string
+	Events   []state.Event
+	Err      error
+}
+
+// historyState is the full-screen event timeline of one session.
+type historyState struct {
+	SessionName string
+	FullName    string
+	Host        string
+	Events      []state.Event
+	Spans       map[int64]session.Span // status event ID -> time spent in that status
+	LoadedAt    time.Time
+	Loaded      bool
+	Err         error
+	Offset      int // first visible event
+}
+
+// recordListing notes a successful listing of host and records in the
+// history what changed since the last one, returning the sessions that
+// appeared or went. They are only known once the host has been listed
+// successfully before; until then the listing is reconciled with the
+// recorded history instead. Left to `crabctl daemon` while it runs.
+func (m *Model) recordListing(host string, sessions []session.Session, transitions []session.Transition) (appeared, gone []session.Session) {
+	listed := m.listed[host]
+	m.listed[host] = true
+	if m.daemonRunning {
+		return nil, nil
+	}
+	now := time.Now()
+	if !listed {
+		_ = session.ReconcileHistory(m.store, host, sessions, now)
+		return nil, nil
+	}
+	appeared, gone = session.Diff(filterHost(m.sessions, host), sessions)
+	session.RecordListing(m.store, appeared, gone, transitions, now)
+	return appeared, gone
+}
+
+// openHistory opens the timeline of the selected session.
+func (m Model) openHistory() (tea.Model, tea.Cmd) {
+	sel := m.selectedSession()
+	if sel == nil {
+		return m, nil
+	}
+	m.history = &historyState{SessionName: sel.Name, FullName: sel.FullName, Host: sel.Host}
+	if m.store == nil {
+		m.history.Loaded = true
+		m.history.Err = errors.New("no state database")
+		return m, nil
+	}
+	store := m.store
+	fullName, host := sel.FullName, sel.Host
+	return m, func() tea.Msg {
+		events, err := store.ListEvents(fullName, host, time.Time{})
+		return historyLoadedMsg{FullName: fullName, Events: events, Err: err}
+	}
+}
+
+// setHistory fills the timeline once loaded, scrolled to the latest event.
+func (m *Model) setHistory(msg historyLoadedMsg) {
+	h := m.history
+	if h == nil || h.FullName != msg.FullName {
+		return
+	}
+	h.Events, h.Err, h.Loaded = msg.Events, msg.Err, true
+	h.LoadedAt = time.Now()
+	h.Spans = make(map[int64]session.Span)
+	for _, sp := range session.Spans(msg.Events, h.LoadedAt) {
+		h.Spans[sp.EventID] = sp
+	}
+	h.Offset = max(0, len(h.Events)-m.historyHeight())
+}
+
+// historyHeight is the number of events that fit on screen.
+// Budget: title+blank(2) + summary+blank(2) + borders(2) + help(1) + safety(1)
+func (m Model) historyHeight() int {
+	return max(3, m.height-8)
+}
+
+func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := m.history
+	page := m.historyHeight()
+	switch {
+	case key.Matches(msg, keys.Up):
+		h.Offset--
+	case key.Matches(msg, keys.Down):
+		h.Offset++
+	case key.Matches(msg, keys.PageUp):
+		h.Offset -= page - 1
+	case key.Matches(msg, keys.PageDown):
+		h.Offset += page - 1
+	case key.Matches(msg, keys.Top):
+		h.Offset = 0
+	case key.Matches(msg, keys.Bottom):
+		h.Offset = len(h.Events)
+	}
+	h.Offset = max(0, min(h.Offset, len(h.Events)-page))
+	return m, nil
+}
+
+func (m Model) renderHistory(b *strings.Builder) {
+	h := m.history
+	borderTitle := fmt.Sprintf(" ─── history: %s ", h.SessionName)
+	if remaining := m.width - lipgloss.Width(borderTitle) - 2; remaining > 0 {
+		borderTitle += strings.Repeat("─", remaining)
+	}
+	b.WriteString(previewBorderStyle.Render(" " + borderTitle))
+	b.WriteString("\n")
+
+	switch {
+	case !h.Loaded:
+		b.WriteString(previewContentStyle.Render(" Loading..."))
+		b.WriteString("\n")
+	case h.Err != nil:
+		b.WriteString(previewContentStyle.Render(" Error: " + h.Err.Error()))
+		b.WriteString("\n")
+	case len(h.Events) == 0:
+		b.WriteString(previewContentStyle.Render(" No events recorded yet."))
+		b.WriteString("\n")
+	default:
+		b.WriteString(" " + m.renderTimeInStatus())
+		b.WriteString("\n\n")
+		end := min(h.Offset+m.historyHeight(), len(h.Events))
+		for _, ev := range h.Events[h.Offset:end] {
+			line := helpStyle.Render(ev.Time.Local().Format("01-02 15:04:05")) + "  " +
+				pad(ev.Kind, 11) + " " + previewContentStyle.Render(session.DescribeEvent(ev))
+			if sp, ok := h.Spans[ev.ID]; ok {
+				spent := session.FormatDuration(sp.Duration())
+				if sp.Open {
+					spent += " so far"
+				}
+				line += "  " + actionStyle.Render(spent)
+			}
+			b.WriteString(" " + ansi.Truncate(line, max(0, m.width-2), "…"))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(previewBorderStyle.Render(" " + strings.Repeat("─", max(0, m.width-2))))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("j/k scroll  pgup/pgdn page  g/G top/bottom  esc close"))
+	b.WriteString("\n")
+}
+
+// renderTimeInStatus sums the time the session spent in each status, e.g.
+// "running 1h 20m  waiting 12m  permission 3m".
+func (m Model) renderTimeInStatus() string {
+	total := make(map[string]time.Duration)
+	for _, sp := range m.history.Spans {
+		total[sp.Status] += sp.Duration()
+	}
+	var parts []string
+	for _, st := range []session.Status{session.Running, session.Waiting, session.Permission, session.Confirm, session.TaskDone} {
+		if d, ok := total[st.String()]; ok {
+			parts = append(parts, renderStatusWithAge(session.Session{Status: st})+" "+actionStyle.Render(session.FormatDuration(d)))
+		}
+	}
+	if len(parts) == 0 {
+		return helpStyle.Render("No status recorded yet.")
+	}
+	return strings.Join(parts, "  ")
+}
@@ -8,6 +8,27 @@ type keyMap struct {
 	Enter       key.Binding
 	Kill        key.Binding
 	AutoForward key.Binding
+	AFSettings  key.Binding
+	Mute        key.Binding
+	ResumeAll   key.Binding
+	Transcript  key.Binding
+	History     key.Binding
+	StatsGroup  key.Binding
+	StatsWindow key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
+	Search      key.Binding
+	NextMatch   key.Binding
+	PrevMatch   key.Binding
+	ToggleTools key.Binding
+	Export      key.Binding
+	Approve     key.Binding
+	AlwaysAllow key.Binding
+	Deny        key.Binding
+	ApproveAll  key.Binding
+	Queue       key.Binding
 	Escape      key.Binding
 	Quit        key.Binding
 	CtrlC       key.Binding
@@ -29,6 +50,69 @@ var keys = keyMap{
 	AutoForward: key.NewBinding(
 		key.WithKeys("ctrl+a"),
 	),
+	AFSettings: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+	),
+	Mute: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+	),
+	ResumeAll: key.NewBinding(
+		key.WithKeys("tab"),
+	),
+	Transcript: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+	),
+	StatsGroup: key.NewBinding(
+		key.WithKeys("tab"),
+	),
+	StatsWindow: key.NewBinding(
+		key.WithKeys("w"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup", "ctrl+b"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", "ctrl+f", " "),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("home", "g"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end", "G"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+	),
+	ToggleTools: key.NewBinding(
+		key.WithKeys("t"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+	),
+	Approve: key.NewBinding(
+		key.WithKeys("y"),
+	),
+	AlwaysAllow: key.NewBinding(
+		key.WithKeys("a"),
+	),
+	Deny: key.NewBinding(
+		key.WithKeys("n"),
+	),
+	ApproveAll: key.NewBinding(
+		key.WithKeys("A"),
+	),
+	Queue: key.NewBinding(
+		key.WithKeys("ctrl+q"),
+	),
 	Escape: key.NewBinding(
 		key.WithKeys("esc"),
 	),
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+)
+
+type menuAnsweredMsg struct {
+	FullName string
+	Host     string
+	Option   session.MenuOption
+	Err      error
+}
+
+// handleMenuKey answers the previewed session's numbered menu: a digit picks
+// that option, and enter with text picks the menu's free-text option and
+// types the text. Returns false when the key isn't a menu answer.
+func (m Model) handleMenuKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if m.preview == nil || m.preview.Menu == nil {
+		return m, nil, false
+	}
+	text := strings.TrimSpace(m.input.Value())
+
+	if text == "" {
+		k := msg.String()
+		if len(k) != 1 || k[0] < '1' || k[0] > '9' {
+			return m, nil, false
+		}
+		if int(k[0]-'0') > len(m.preview.Menu.Options) {
+			return m, nil, true
+		}
+		return m, m.answerMenuCmd(k), true
+	}
+
+	if msg.Type == tea.KeyEnter && m.preview.Menu.TextOption() != nil {
+		m.input.SetValue("")
+		return m, m.answerMenuCmd(text), true
+	}
+	return m, nil, false
+}
+
+// answerMenuCmd answers the previewed session's menu in the background;
+// session.AnswerMenu re-reads the pane, so a menu that has gone away is
+// reported instead of receiving stray keys.
+func (m Model) answerMenuCmd(answer string) tea.Cmd {
+	exec := m.findExecutor(m.preview.Host)
+	fullName, host := m.preview.FullName, m.preview.Host
+	return func() tea.Msg {
+		opt, err := session.AnswerMenu(exec, fullName, answer)
+		return menuAnsweredMsg{FullName: fullName, Host: host, Option: opt, Err: err}
+	}
+}
+
+// renderMenu renders the previewed session's menu as a picker, one line
+// per option with the currently highlighted one marked.
+func (m Model) renderMenu(b *strings.Builder) {
+	for _, o := range m.preview.Menu.Options {
+		line := fmt.Sprintf(" [%d] %s", o.Number, o.Label)
+		if o.Selected {
+			b.WriteString(cursorStyle.Render(line))
+		} else {
+			b.WriteString(actionStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+}
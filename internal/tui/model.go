@@ -2,9 +2,6 @@ package tui
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -15,30 +12,42 @@ import (
 	"github.com/simon/crabctl/internal/session"
 	"github.com/simon/crabctl/internal/state"
 	"github.com/simon/crabctl/internal/tmux"
+	"github.com/simon/crabctl/internal/webhook"
 )
 
 const pollInterval = 1500 * time.Millisecond
 const remotePollInterval = 5 * time.Second
 const maxRemotePollInterval = 60 * time.Second
 const spinnerInterval = 100 * time.Millisecond
-const autoForwardDelay = 10 * time.Second
-const maxAutoForwards = 5
-// AutoForwardMessage is the message sent to sessions with autoforward enabled.
-const AutoForwardMessage = `Continue working until done. Say "TASK_DONE!" (swap _ for space) if you really think you're done.`
-
-var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
 type tickMsg time.Time
 type remoteTickMsg time.Time
 type spinnerTickMsg time.Time
 
 type sessionCreatedMsg struct {
+	Name    string
+	Host    string
+	Created session.Created
+	Err     error
+}
+
+// initialMessageSentMsg reports sending a template's initial message to a
+// session created with /new -t.
+type initialMessageSentMsg struct {
 	Name string
+	Host string
 	Err  error
 }
 
 type sessionKilledMsg struct {
-	Name string
+	Label    string
+	Worktree *state.Worktree // created by `crabctl new --worktree`, offered for removal
+	Err      error
+}
+
+type worktreeRemovedMsg struct {
+	Path string
+	Err  error
 }
 
 // remoteSessionsMsg carries sessions from a single remote host.
@@ -46,10 +55,13 @@ type sessionKilledMsg struct {
 type remoteSessionsMsg struct {
 	Host     string
 	Sessions []session.Session
+	Err      error
 }
 
 type autoForwardSentMsg struct {
 	FullName string
+	Host     string
+	Message  string
 }
 
 type claudeSessionsMsg []session.ClaudeSession
@@ -64,16 +76,14 @@ type previewState struct {
 	FullName    string
 	Host        string
 	Output      string
+	Menu        *session.Menu // numbered menu in Output, if not a permission prompt
 }
 
 type confirmAction struct {
-	SessionName     string
-	FullName        string
-	Host            string
-	WorkDir         string
-	SessionUUID     string
-	SessionFirstMsg string
-	Killing         bool // true while kill is in progress
+	SessionName string
+	FullName    string
+	Host        string
+	Killing     bool // true while kill is in progress
 }
 
 // RestoreState carries state between TUI restarts (after detaching from a session).
@@ -89,20 +99,37 @@ type Model struct {
 	scrollOffset  int
 	input         textinput.Model
 	preview       *previewState
+	transcript    *transcriptState
+	history       *historyState // open event timeline
+	stats         *statsState   // open time-in-status report
+	notice        string // one-off message shown in the help bar until the next key
 	confirmKill   *confirmAction
+	confirmWorktree *state.Worktree // worktree of a just killed session, pending removal
 	executors     []tmux.Executor
 	remoteLoading  map[string]bool // hosts still being fetched (initial load)
 	remoteFetching bool           // true while a remote refresh is in-flight
 	spinnerFrame   int
 	restore       *RestoreState
 	store            *state.Store         // persistent state (nil-safe)
+	pricing          session.Pricing      // price table for the COST column
+	policy           *session.Policy      // auto-approval rules for permission prompts (nil = none)
+	policyPending    map[string]string    // fullName -> prompt summary already answered by policy
+	queueDepth       map[string]int       // state.QueueKey -> messages queued until idle
+	queue            *session.QueueDispatcher
 	// Auto-forward: automatically send "continue" when session waits
-	autoForward      map[string]bool      // fullName -> enabled
-	autoForwardCount map[string]int       // fullName -> consecutive forwards sent
-	waitingSince     map[string]time.Time // fullName -> when first seen waiting
+	autoForward      *session.AutoForwarder
+	afDialog         *autoForwardDialog // open autoforward settings dialog
+	daemonRunning    bool               // `crabctl daemon` does autoforward, queue delivery and notifications
+	notifier         *session.Notifier  // status change notifications from the config
+	webhooks         *webhook.Sender    // webhook targets from the config
+	webhookQueue     *webhook.Queue     // sends webhook events in order in the background
+	webhookErrs      chan error         // failed webhook deliveries, reported as notices
+	listed           map[string]bool    // hosts listed successfully, so new and gone sessions are known
+	muted            map[string]bool    // QueueKey -> notifications muted
 	// Resume mode: browse past Claude sessions to resume
 	pendingFocus   string // full session name to focus+preview after resume
 	resumeMode     bool
+	resumeAll      bool // include all Claude conversations on disk, not just crabs
 	resumeSessions []session.ClaudeSession
 	resumeFiltered []session.ClaudeSession
 	resumeCursor   int
@@ -148,17 +175,49 @@ func NewModel(executors []tmux.Executor, restore *RestoreState, store *state.Sto
 		executors:        executors,
 		remoteLoading:    loading,
 		store:            store,
-		autoForward:      make(map[string]bool),
-		autoForwardCount: make(map[string]int),
-		waitingSince:     make(map[string]time.Time),
+		pricing:          session.LoadPricing(),
+		autoForward:      session.NewAutoForwarder(session.LoadAutoForwardDefaults()),
+		policyPending:    make(map[string]string),
+		queueDepth:       make(map[string]int),
+		queue:            session.NewQueueDispatcher(),
+		muted:            make(map[string]bool),
+		listed:           make(map[string]bool),
 		lastInteraction:  time.Now(),
 	}
 
-	// Load autoforward state from DB
-	if store != nil {
-		if af, err := store.LoadAllAutoForward(); err == nil {
-			m.autoForward = af
+	policy, err := session.LoadPolicy()
+	if err != nil {
+		m.notice = fmt.Sprintf("policy.yaml ignored: %v", err)
+	}
+	m.policy = policy
+
+	notifier, err := session.LoadNotifier()
+	if err != nil {
+		m.notice = fmt.Sprintf("notify config ignored: %v", err)
+	}
+	m.notifier = notifier
+
+	webhooks, err := webhook.Load()
+	if err != nil {
+		m.notice = fmt.Sprintf("webhooks ignored: %v", err)
+	}
+	m.webhooks = webhooks
+	errs := make(chan error, 16)
+	m.webhookErrs = errs
+	m.webhookQueue = webhook.NewQueue(webhooks, func(ev webhook.Event, err error) {
+		select {
+		case errs <- fmt.Errorf("%s: %w", ev.Event, err):
+		default:
 		}
+	})
+
+	// Load autoforward state from DB
+	m.autoForward.Sync(store)
+	m.syncQueueFromDB()
+	m.syncMutedFromDB()
+	_, m.daemonRunning = state.DaemonPID()
+	if store != nil && !m.daemonRunning {
+		_, _ = session.PruneHistory(store, time.Now())
 	}
 
 	// Restore cached sessions and focus from previous TUI instance
@@ -217,6 +276,9 @@ func (m Model) Init() tea.Cmd {
 		m.refreshLocalSessions,
 		tickCmd(),
 	}
+	if m.webhooks.Enabled() {
+		cmds = append(cmds, waitWebhookErr(m.webhookErrs))
+	}
 	if len(m.remoteLoading) > 0 {
 		cmds = append(cmds, spinnerTickCmd())
 		cmds = append(cmds, m.refreshRemoteSessions()...)
@@ -227,14 +289,18 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-// refreshLocalSessions fetches only local sessions (fast).
+// refreshLocalSessions fetches only local sessions (fast). Session state is
+// merged here rather than in Update since resolving UUIDs and re-reading
+// token usage parse session files.
 func (m Model) refreshLocalSessions() tea.Msg {
 	for _, ex := range m.executors {
 		if ex.HostName() == "" {
-			sessions, err := session.ListExecutor(ex)
+			hooks, _ := m.store.LoadHookEvents()
+			sessions, err := session.ListExecutor(ex, hooks)
 			if err != nil {
 				return err
 			}
+			session.MergeState(ex, m.store, m.pricing, filterHost(m.sessions, ""), sessions)
 			return sessions
 		}
 	}
@@ -242,23 +308,34 @@ func (m Model) refreshLocalSessions() tea.Msg {
 }
 
 // refreshRemoteSessions returns commands that fetch each remote host in parallel.
+// Session state is merged in the background since resolving UUIDs of new
+// sessions reads their session files over SSH.
 func (m Model) refreshRemoteSessions() []tea.Cmd {
 	var cmds []tea.Cmd
 	for _, ex := range m.executors {
 		if ex.HostName() != "" {
-			ex := ex // capture
-			cmds = append(cmds, func() tea.Msg {
-				sessions, _ := session.ListExecutor(ex)
-				return remoteSessionsMsg{
-					Host:     ex.HostName(),
-					Sessions: sessions,
-				}
-			})
+			cmds = append(cmds, m.refreshHostCmd(ex))
 		}
 	}
 	return cmds
 }
 
+// refreshHostCmd fetches sessions from a single remote host.
+func (m Model) refreshHostCmd(ex tmux.Executor) tea.Cmd {
+	known := filterHost(m.sessions, ex.HostName())
+	store := m.store
+	pricing := m.pricing
+	return func() tea.Msg {
+		sessions, err := session.ListExecutor(ex, nil)
+		session.MergeState(ex, store, pricing, known, sessions)
+		return remoteSessionsMsg{
+			Host:     ex.HostName(),
+			Sessions: sessions,
+			Err:      err,
+		}
+	}
+}
+
 func (m Model) capturePreviewCmd(fullName, host string) tea.Cmd {
 	exec := m.findExecutor(host)
 	return func() tea.Msg {
@@ -266,7 +343,7 @@ func (m Model) capturePreviewCmd(fullName, host string) tea.Cmd {
 		if err != nil {
 			return previewOutputMsg{FullName: fullName, Output: "Error: " + err.Error()}
 		}
-		return previewOutputMsg{FullName: fullName, Output: cleanPreviewOutput(output)}
+		return previewOutputMsg{FullName: fullName, Output: session.CleanPaneOutput(output)}
 	}
 }
 
@@ -292,7 +369,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case sessionKilledMsg:
 		m.confirmKill = nil
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("%s: kill failed: %v", msg.Label, msg.Err)
+			return m, nil
+		}
 		m.preview = nil
+		m.confirmWorktree = msg.Worktree
 		cmds := []tea.Cmd{m.refreshLocalSessions}
 		cmds = append(cmds, m.refreshRemoteSessions()...)
 		return m, tea.Batch(cmds...)
@@ -304,11 +386,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.input.SetValue("")
 		m.resumeMode = false
-		return m, m.refreshLocalSessions
+		refresh := m.refreshLocalSessions
+		if msg.Host != "" {
+			refresh = m.refreshHostCmd(m.findExecutor(msg.Host))
+		}
+		if msg.Err == nil && msg.Created.Message != "" {
+			return m, tea.Batch(refresh, sendInitialMessageCmd(msg.Name, msg.Host, msg.Created))
+		}
+		return m, refresh
 
 	case []session.Session:
-		// Carry forward already-resolved session state (UUIDs, PR URLs)
-		m.mergeSessionState(msg)
+		transitions := session.Transitions(filterHost(m.sessions, ""), msg)
+		cmds := m.notifyTransitions(transitions)
+		appeared, gone := m.recordListing("", msg, transitions)
+		m.sendWebhooks(m.webhookEvents(appeared, gone, transitions))
 		// Local sessions replace only local entries, preserve remote
 		remote := filterByHost(m.sessions, true)
 		m.sessions = append(msg, remote...)
@@ -323,25 +414,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.focusSession(m.restore.FocusSession)
 			m.restore = nil
 		}
-		// Auto-focus + preview after resume
-		if m.pendingFocus != "" {
-			m.focusSession(m.pendingFocus)
-			if sel := m.selectedSession(); sel != nil && sel.FullName == m.pendingFocus {
-				m.preview = &previewState{
-					SessionName: sel.Name,
-					FullName:    sel.FullName,
-					Host:        sel.Host,
-				}
-				m.pendingFocus = ""
-				return m, m.capturePreviewCmd(sel.FullName, sel.Host)
-			}
-		}
-		return m, nil
+		return m, tea.Batch(append(cmds, m.applyPendingFocus())...)
 
 	case remoteSessionsMsg:
 		// Clear loading/fetching state for this host
 		delete(m.remoteLoading, msg.Host)
 		m.remoteFetching = false
+		transitions := session.Transitions(filterHost(m.sessions, msg.Host), msg.Sessions)
+		cmds := m.notifyTransitions(transitions)
+		if msg.Err != nil {
+			// What appeared or went is unknown until the next good listing
+			m.listed[msg.Host] = false
+		} else {
+			appeared, gone := m.recordListing(msg.Host, msg.Sessions, transitions)
+			m.sendWebhooks(m.webhookEvents(appeared, gone, transitions))
+		}
 		// Replace sessions for this specific host, keep everything else
 		var kept []session.Session
 		for _, s := range m.sessions {
@@ -356,8 +443,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if prevFocus != "" {
 			m.focusSession(prevFocus)
 		}
+		return m, tea.Batch(append(cmds, m.applyPendingFocus())...)
+
+	case worktreeRemovedMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("kept worktree %s: %v", msg.Path, msg.Err)
+		} else {
+			m.notice = "Removed worktree " + msg.Path
+		}
+		return m, nil
+
+	case notifyFailedMsg:
+		m.notice = fmt.Sprintf("%s: notification failed: %v", msg.Label, msg.Err)
 		return m, nil
 
+	case webhookFailedMsg:
+		m.notice = fmt.Sprintf("webhook failed: %v", msg.Err)
+		return m, waitWebhookErr(m.webhookErrs)
+
 	case error:
 		m.err = msg
 		return m, nil
@@ -371,18 +474,52 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case autoForwardSentMsg:
-		m.autoForwardCount[msg.FullName]++
+		m.autoForward.Sent(msg.FullName, msg.Host)
+		_ = m.store.RecordEvent(state.Event{Name: msg.FullName, Host: msg.Host, Kind: state.EventAutoForward, Detail: msg.Message})
+		for _, s := range m.sessions {
+			if s.FullName == msg.FullName && s.Host == msg.Host {
+				m.sendWebhooks([]webhook.Event{webhook.AutoForwarded(s, time.Now())})
+				return m, nil
+			}
+		}
 		return m, nil
 
 	case tickMsg:
-		m.syncAutoForwardFromDB()
+		m.autoForward.Sync(m.store)
+		m.syncQueueFromDB()
+		m.syncMutedFromDB()
+		_, m.daemonRunning = state.DaemonPID()
 		cmds := []tea.Cmd{tickCmd(), m.refreshLocalSessions}
 		if m.preview != nil && !m.resumeMode {
 			cmds = append(cmds, m.capturePreviewCmd(m.preview.FullName, m.preview.Host))
 		}
 		cmds = append(cmds, m.checkAutoForward()...)
+		cmds = append(cmds, m.checkPolicy()...)
+		cmds = append(cmds, m.checkQueue()...)
 		return m, tea.Batch(cmds...)
 
+	case queueDeliveredMsg:
+		if msg.Skipped {
+			m.queue.Retry(msg.Key)
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("%s: queued message not sent: %v", msg.Label, msg.Err)
+			return m, nil
+		}
+		if m.queueDepth[msg.Key] > 0 {
+			m.queueDepth[msg.Key]--
+		}
+		m.notice = fmt.Sprintf("%s: sent queued message (%d left)", msg.Label, m.queueDepth[msg.Key])
+		return m, nil
+
+	case policyAppliedMsg:
+		m.notice = policyNotice(msg.Decision)
+		if msg.Decision.Host != "" {
+			return m, m.refreshHostCmd(m.findExecutor(msg.Decision.Host))
+		}
+		return m, m.refreshLocalSessions
+
 	case remoteTickMsg:
 		cmds := []tea.Cmd{remoteTickCmd(m.remoteInterval())}
 		if !m.remoteFetching && m.hasRemoteHosts() {
@@ -391,9 +528,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 
+	case initialMessageSentMsg:
+		label := session.Label(msg.Host, msg.Name)
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("%s: initial message not sent: %v", label, msg.Err)
+		} else {
+			m.notice = fmt.Sprintf("%s: sent initial message", label)
+		}
+		return m, nil
+
+	case permissionAnsweredMsg:
+		label := session.Label(msg.Host, msg.Name)
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("%s: %v", label, msg.Err)
+		} else {
+			m.notice = fmt.Sprintf("%s: %s %s", label, answerLabels[msg.Answer], msg.Summary)
+		}
+		if msg.Host != "" {
+			return m, m.refreshHostCmd(m.findExecutor(msg.Host))
+		}
+		return m, m.refreshLocalSessions
+
+	case historyLoadedMsg:
+		m.setHistory(msg)
+		return m, nil
+
+	case statsLoadedMsg:
+		m.setStats(msg)
+		return m, nil
+
+	case transcriptLoadedMsg:
+		if m.transcript != nil && m.transcript.FullName == msg.FullName {
+			m.transcript.Entries = msg.Entries
+			m.transcript.Err = msg.Err
+			m.transcript.Loaded = true
+			m.layoutTranscript()
+			// Start at the end of the conversation
+			m.transcript.Offset = len(m.transcript.lines)
+			m.clampTranscriptOffset()
+		}
+		return m, nil
+
 	case previewOutputMsg:
 		if m.preview != nil && m.preview.FullName == msg.FullName {
 			m.preview.Output = msg.Output
+			m.preview.Menu = nil
+			if session.ParsePermissionPrompt(msg.Output) == nil {
+				m.preview.Menu = session.ParseMenu(msg.Output)
+			}
+		}
+		return m, nil
+
+	case menuAnsweredMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("answer failed: %v", msg.Err)
+		} else {
+			m.notice = fmt.Sprintf("picked %d. %s", msg.Option.Number, msg.Option.Label)
+		}
+		if m.preview != nil && m.preview.FullName == msg.FullName {
+			return m, m.capturePreviewCmd(msg.FullName, msg.Host)
 		}
 		return m, nil
 
@@ -401,11 +594,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.input.Width = msg.Width - 4
+		if m.transcript != nil && m.transcript.Loaded {
+			m.layoutTranscript()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
 		wasIdle := m.remoteInterval() > remotePollInterval
 		m.lastInteraction = time.Now()
+		m.notice = ""
 		ret, cmd := m.handleKey(msg)
 		if wasIdle && m.hasRemoteHosts() && !m.remoteFetching {
 			m.remoteFetching = true
@@ -420,6 +617,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// applyPendingFocus focuses and previews a just created or resumed session
+// once it shows up in the session list.
+func (m *Model) applyPendingFocus() tea.Cmd {
+	if m.pendingFocus == "" {
+		return nil
+	}
+	m.focusSession(m.pendingFocus)
+	sel := m.selectedSession()
+	if sel == nil || sel.FullName != m.pendingFocus {
+		return nil
+	}
+	m.preview = &previewState{
+		SessionName: sel.Name,
+		FullName:    sel.FullName,
+		Host:        sel.Host,
+	}
+	m.pendingFocus = ""
+	return m.capturePreviewCmd(sel.FullName, sel.Host)
+}
+
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Ctrl+C always quits
 	if key.Matches(msg, keys.CtrlC) {
@@ -427,8 +644,40 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// After a kill, Enter removes the session's worktree; any other key keeps it
+	if m.confirmWorktree != nil {
+		wt := *m.confirmWorktree
+		m.confirmWorktree = nil
+		if key.Matches(msg, keys.Enter) {
+			return m, m.removeWorktreeCmd(wt)
+		}
+		m.notice = "Kept worktree " + wt.Path
+		return m, nil
+	}
+
 	// Escape
 	if key.Matches(msg, keys.Escape) {
+		if m.afDialog != nil {
+			m.afDialog = nil
+			return m, nil
+		}
+		if m.history != nil {
+			m.history = nil
+			return m, nil
+		}
+		if m.stats != nil {
+			m.stats = nil
+			return m, nil
+		}
+		if m.transcript != nil {
+			if m.transcript.Searching {
+				m.transcript.Searching = false
+			} else {
+				m.transcript = nil
+			}
+			m.input.SetValue("")
+			return m, nil
+		}
 		if m.confirmKill != nil {
 			m.confirmKill = nil
 			return m, nil
@@ -464,16 +713,43 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Autoforward settings dialog takes all other keys while open
+	if m.afDialog != nil {
+		return m.handleAutoForwardDialogKey(msg)
+	}
+
+	// Transcript viewer takes all other keys while open
+	if m.transcript != nil {
+		return m.handleTranscriptKey(msg)
+	}
+
+	// Event timeline takes all other keys while open
+	if m.history != nil {
+		return m.handleHistoryKey(msg)
+	}
+
+	// So does the stats panel
+	if m.stats != nil {
+		return m.handleStatsKey(msg)
+	}
+
+	// Ctrl+T: open the full transcript of the selected session
+	if key.Matches(msg, keys.Transcript) && !m.resumeMode {
+		return m.openTranscript()
+	}
+
+	// Ctrl+G: open the event timeline of the selected session
+	if key.Matches(msg, keys.History) && !m.resumeMode {
+		return m.openHistory()
+	}
+
 	// Ctrl+K: kill selected session (not in resume mode)
 	if key.Matches(msg, keys.Kill) && !m.resumeMode {
 		if sel := m.selectedSession(); sel != nil {
 			m.confirmKill = &confirmAction{
-				SessionName:     sel.Name,
-				FullName:        sel.FullName,
-				Host:            sel.Host,
-				WorkDir:         sel.WorkDir,
-				SessionUUID:     sel.SessionUUID,
-				SessionFirstMsg: sel.SessionFirstMsg,
+				SessionName: sel.Name,
+				FullName:    sel.FullName,
+				Host:        sel.Host,
 			}
 		}
 		return m, nil
@@ -482,11 +758,24 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Ctrl+A: toggle autoforward on selected session
 	if key.Matches(msg, keys.AutoForward) && !m.resumeMode {
 		if sel := m.selectedSession(); sel != nil {
-			m.ToggleAutoForward(sel.FullName)
+			m.ToggleAutoForward(sel.FullName, sel.Host)
+		}
+		return m, nil
+	}
+
+	// Ctrl+X: mute or unmute notifications for the selected session
+	if key.Matches(msg, keys.Mute) && !m.resumeMode {
+		if sel := m.selectedSession(); sel != nil {
+			m.ToggleMute(sel.FullName, sel.Host, sel.Label())
 		}
 		return m, nil
 	}
 
+	// Ctrl+O: edit the autoforward settings of the selected session
+	if key.Matches(msg, keys.AFSettings) && !m.resumeMode {
+		return m.openAutoForwardDialog()
+	}
+
 	// q quits only when input is empty and no preview/resume
 	if key.Matches(msg, keys.Quit) && m.input.Value() == "" && m.preview == nil && !m.resumeMode {
 		m.quitting = true
@@ -531,47 +820,21 @@ func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		text := strings.TrimSpace(m.input.Value())
 
 		// /new command: create a new session
-		if cmd := parseNewCommand(text); cmd != nil {
+		if cmd := m.parseNewCommand(text); cmd != nil {
 			m.input.SetValue("")
 			return m, cmd
 		}
 
 		// /resume command: browse past sessions from DB
+		// ("/resume all" includes every Claude conversation on disk)
 		if text == "/resume" || strings.HasPrefix(text, "/resume ") {
-			store := m.store
-			executors := m.executors
-			return m, func() tea.Msg {
-				if store == nil {
-					return claudeSessionsMsg(nil)
-				}
-				past, err := store.ListResumable(100)
-				if err != nil {
-					return claudeSessionsMsg(nil)
-				}
-				// Collect active session names to filter them out
-				active := make(map[string]bool)
-				for _, ex := range executors {
-					infos, _ := ex.ListSessions()
-					for _, info := range infos {
-						active[info.FullName] = true
-					}
-				}
-				var sessions []session.ClaudeSession
-				for _, ps := range past {
-					if active[ps.Name] {
-						continue
-					}
-					sessions = append(sessions, session.ClaudeSession{
-						Name:         ps.Name,
-						UUID:         ps.SessionUUID,
-						ProjectDir:   ps.WorkDir,
-						ModTime:      ps.LastSeen,
-						FirstMessage: ps.FirstMsg,
-						Killed:       ps.Killed,
-					})
-				}
-				return claudeSessionsMsg(sessions)
-			}
+			m.resumeAll = strings.TrimSpace(strings.TrimPrefix(text, "/resume")) == "all"
+			return m, m.loadResumeSessionsCmd(m.resumeAll)
+		}
+
+		// /stats command: where the sessions' time went
+		if text == "/stats" {
+			return m.openStats()
 		}
 
 		// Open preview
@@ -596,6 +859,16 @@ func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handlePreviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// y/a/n answer the previewed session's permission prompt. They're only
+	// bound here so they can't collide with typing a filter in the list.
+	if ret, cmd, ok := m.handlePermissionKey(msg, m.selectedSession()); ok {
+		return ret, cmd
+	}
+	// 1-9 (or text+enter) answer a numbered menu such as plan approval
+	if ret, cmd, ok := m.handleMenuKey(msg); ok {
+		return ret, cmd
+	}
+
 	// Navigation: switch between sessions while previewing
 	if m.input.Value() == "" {
 		if key.Matches(msg, keys.Up) {
@@ -614,6 +887,15 @@ func (m Model) handlePreviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// ctrl+q queues the text until the session is idle
+	if key.Matches(msg, keys.Queue) {
+		if text := strings.TrimSpace(m.input.Value()); text != "" {
+			m.enqueue(text)
+			m.input.SetValue("")
+		}
+		return m, nil
+	}
+
 	// Enter
 	if key.Matches(msg, keys.Enter) {
 		text := strings.TrimSpace(m.input.Value())
@@ -627,7 +909,9 @@ func (m Model) handlePreviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		// Send text to session
 		exec := m.findExecutor(m.preview.Host)
-		_ = exec.SendKeys(m.preview.FullName, text)
+		if err := exec.SendKeys(m.preview.FullName, text); err == nil {
+			_ = m.store.RecordEvent(state.Event{Name: m.preview.FullName, Host: m.preview.Host, Kind: state.EventSend, Detail: text})
+		}
 		m.input.SetValue("")
 		return m, m.capturePreviewCmd(m.preview.FullName, m.preview.Host)
 	}
@@ -657,218 +941,73 @@ func (m Model) executeKill() (Model, tea.Cmd) {
 	m.confirmKill.Killing = true
 	fullName := m.confirmKill.FullName
 	host := m.confirmKill.Host
-	name := m.confirmKill.SessionName
-	workDir := m.confirmKill.WorkDir
-	preUUID := m.confirmKill.SessionUUID
-	preFirstMsg := m.confirmKill.SessionFirstMsg
+	label := session.Label(host, m.confirmKill.SessionName)
 	exec := m.findExecutor(host)
 	store := m.store
 	killCmd := func() tea.Msg {
-		uuid, firstMsg := preUUID, preFirstMsg
-		if uuid == "" {
-			// Fallback: match now if not resolved at discovery
-			paneContent, _ := exec.CapturePaneOutput(fullName, 50)
-			created := tmux.GetSessionCreated(fullName)
-			uuid, firstMsg = session.FindSessionUUID(workDir, created, paneContent, nil)
+		if err := session.Kill(exec, store, fullName); err != nil {
+			return sessionKilledMsg{Label: label, Err: err}
 		}
-		_ = exec.KillSession(fullName)
-		// Record killed session in DB
-		if store != nil && uuid != "" {
-			store.MarkKilled(fullName, uuid, workDir, firstMsg)
+		msg := sessionKilledMsg{Label: label}
+		if store != nil {
+			if wt, ok := store.GetWorktree(fullName, host); ok {
+				msg.Worktree = &wt
+			}
 		}
-		return sessionKilledMsg{Name: name}
+		return msg
 	}
 	return m, tea.Batch(killCmd, spinnerTickCmd())
 }
 
-// mergeSessionState carries forward already-resolved UUIDs and PR URLs
-// from old sessions, resolving new ones only when first discovered.
-func (m *Model) mergeSessionState(sessions []session.Session) {
-	// Build lookup from existing sessions
-	known := make(map[string]session.Session)
-	for _, s := range m.sessions {
-		known[s.FullName] = s
-	}
-
-	// Collect all claimed UUIDs so new resolutions skip already-matched files
-	claimed := make(map[string]bool)
-	for _, s := range m.sessions {
-		if s.SessionUUID != "" {
-			claimed[s.SessionUUID] = true
-		}
-	}
-
-	for i := range sessions {
-		s := &sessions[i]
-		if old, ok := known[s.FullName]; ok {
-			// Carry forward stable project directory from first discovery.
-			// Pane's current path can change if Claude cd's.
-			if old.WorkDir != "" {
-				s.WorkDir = old.WorkDir
-			}
-			// Carry forward already-resolved UUID
-			if old.SessionUUID != "" {
-				s.SessionUUID = old.SessionUUID
-				s.SessionFirstMsg = old.SessionFirstMsg
-			}
-			// Carry forward PRURL if the PR number hasn't changed.
-			if old.PRURL != "" && old.PR == s.PR {
-				s.PRURL = old.PRURL
-			}
-		}
-
-		// Resolve UUID for new local sessions
-		if s.SessionUUID == "" && s.Host == "" && s.WorkDir != "" {
-			s.SessionUUID, s.SessionFirstMsg = session.FindSessionUUID(
-				s.WorkDir, time.Now().Add(-s.Duration), s.PaneContent, claimed,
-			)
-			if s.SessionUUID != "" {
-				claimed[s.SessionUUID] = true
-				// Persist to DB so the UUID survives accidental kills
-				if m.store != nil {
-					m.store.SaveSessionUUID(s.FullName, s.SessionUUID, s.WorkDir, s.SessionFirstMsg)
-				}
-			}
-		}
-
-		// Compute LastActive from the known session file (single stat call)
-		if s.SessionUUID != "" && s.Host == "" {
-			s.LastActive = session.SessionFileModTime(s.WorkDir, s.SessionUUID)
+// removeWorktreeCmd removes the worktree of a killed session and forgets it.
+func (m Model) removeWorktreeCmd(wt state.Worktree) tea.Cmd {
+	exec := m.findExecutor(wt.Host)
+	store := m.store
+	return func() tea.Msg {
+		if err := session.RemoveWorktree(exec, wt); err != nil {
+			return worktreeRemovedMsg{Path: wt.Path, Err: err}
 		}
-
-		s.PaneContent = "" // no longer needed after UUID resolution
+		_ = store.DeleteWorktree(wt.Name, wt.Host)
+		return worktreeRemovedMsg{Path: wt.Path}
 	}
 }
 
-// checkAutoForward checks all sessions with autoforward enabled and sends
-// the continue message if they've been waiting for longer than autoForwardDelay.
+// checkAutoForward sends the policy's message to sessions with autoforward
+// enabled that have been waiting for longer than its delay. Left to
+// `crabctl daemon` while it runs.
 func (m *Model) checkAutoForward() []tea.Cmd {
-	now := time.Now()
+	if m.daemonRunning {
+		return nil
+	}
+	// Queued messages take precedence over "continue"
+	queued := func(s session.Session) bool {
+		return m.queueDepth[state.QueueKey(s.Host, s.FullName)] > 0
+	}
 	var cmds []tea.Cmd
-
-	// Update waitingSince tracking for all sessions
-	activeFullNames := make(map[string]bool)
-	for _, s := range m.sessions {
-		activeFullNames[s.FullName] = true
-
-		if !m.autoForward[s.FullName] {
-			continue
-		}
-
-		isWaiting := s.Status == session.Waiting
-		if isWaiting {
-			if _, ok := m.waitingSince[s.FullName]; !ok {
-				m.waitingSince[s.FullName] = now
-			}
-		} else {
-			// Not waiting — reset timer
-			delete(m.waitingSince, s.FullName)
-			// Reset forward count when session starts running again
-			if s.Status == session.Running {
-				m.autoForwardCount[s.FullName] = 0
-			}
-		}
-
-		// Don't auto-forward task-done sessions
-		if s.Status == session.TaskDone {
-			continue
-		}
-
-		// Check if we should forward
-		since, ok := m.waitingSince[s.FullName]
-		if !ok || now.Sub(since) < autoForwardDelay {
-			continue
-		}
-		if m.autoForwardCount[s.FullName] >= maxAutoForwards {
-			continue
-		}
-
-		// Send the continue message (re-check status first to avoid race)
-		fullName := s.FullName
-		host := s.Host
+	for _, s := range m.autoForward.Due(m.sessions, time.Now(), queued) {
+		fullName, host := s.FullName, s.Host
 		exec := m.findExecutor(host)
+		policy := m.autoForward.Policy(fullName, host)
 		cmds = append(cmds, func() tea.Msg {
-			// Re-capture pane to verify still waiting (not TaskDone)
-			output, err := exec.CapturePaneOutput(fullName, 25)
-			if err == nil {
-				status := session.DetectStatus(output)
-				if status != session.Waiting {
-					return nil
-				}
+			if sent, _ := session.Forward(exec, fullName, policy); !sent {
+				return nil
 			}
-			_ = exec.SendKeys(fullName, AutoForwardMessage)
-			return autoForwardSentMsg{FullName: fullName}
+			return autoForwardSentMsg{FullName: fullName, Host: host, Message: policy.Message}
 		})
-		// Reset timer so we wait another 10s
-		m.waitingSince[s.FullName] = now
-	}
-
-	// Clean up tracking for sessions that no longer exist
-	for fn := range m.waitingSince {
-		if !activeFullNames[fn] {
-			delete(m.waitingSince, fn)
-			delete(m.autoForwardCount, fn)
-		}
 	}
-
 	return cmds
 }
 
 // ToggleAutoForward toggles autoforward for the given session.
-func (m *Model) ToggleAutoForward(fullName string) {
-	if m.autoForward[fullName] {
-		delete(m.autoForward, fullName)
-		delete(m.waitingSince, fullName)
-		delete(m.autoForwardCount, fullName)
-		if m.store != nil {
-			_ = m.store.SetAutoForward(fullName, false)
-		}
-	} else {
-		m.autoForward[fullName] = true
-		if m.store != nil {
-			_ = m.store.SetAutoForward(fullName, true)
-		}
-	}
+func (m *Model) ToggleAutoForward(fullName, host string) {
+	m.SetAutoForward(fullName, host, !m.autoForward.Enabled(fullName, host))
 }
 
-// SetAutoForward enables or disables autoforward for a session by name.
-func (m *Model) SetAutoForward(fullName string, enabled bool) {
-	if enabled {
-		m.autoForward[fullName] = true
-	} else {
-		delete(m.autoForward, fullName)
-		delete(m.waitingSince, fullName)
-		delete(m.autoForwardCount, fullName)
-	}
+// SetAutoForward enables or disables autoforward for a session.
+func (m *Model) SetAutoForward(fullName, host string, enabled bool) {
+	m.autoForward.SetEnabled(fullName, host, enabled)
 	if m.store != nil {
-		_ = m.store.SetAutoForward(fullName, enabled)
-	}
-}
-
-// syncAutoForwardFromDB merges DB state into the in-memory autoforward map.
-// Newly enabled sessions are added, newly disabled sessions are removed.
-// Runtime counters (autoForwardCount, waitingSince) are preserved for unchanged sessions.
-func (m *Model) syncAutoForwardFromDB() {
-	if m.store == nil {
-		return
-	}
-	dbState, err := m.store.LoadAllAutoForward()
-	if err != nil {
-		return
-	}
-
-	// Add newly enabled sessions from DB
-	for name := range dbState {
-		m.autoForward[name] = true
-	}
-
-	// Remove sessions disabled in DB
-	for name := range m.autoForward {
-		if !dbState[name] {
-			delete(m.autoForward, name)
-			delete(m.waitingSince, name)
-			delete(m.autoForwardCount, name)
-		}
+		_ = m.store.SetAutoForward(fullName, host, enabled)
 	}
 }
 
@@ -947,6 +1086,17 @@ func (m *Model) ensureCursorVisible() {
 	}
 }
 
+// filterHost returns a copy of the sessions running on the given host.
+func filterHost(sessions []session.Session, host string) []session.Session {
+	var out []session.Session
+	for _, s := range sessions {
+		if s.Host == host {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // filterByHost returns sessions that are remote (non-empty host) or local (empty host).
 func filterByHost(sessions []session.Session, remoteOnly bool) []session.Session {
 	var out []session.Session
@@ -979,46 +1129,92 @@ func (m Model) selectedSession() *session.Session {
 	return &s
 }
 
-func parseNewCommand(text string) tea.Cmd {
+// parseNewCommand handles "/new [host:]name [dir]" and
+// "/new -t template [host:]name [text...]". Returns nil if text isn't a
+// valid /new command.
+func (m Model) parseNewCommand(text string) tea.Cmd {
 	if !strings.HasPrefix(text, "/new ") {
 		return nil
 	}
-	parts := strings.Fields(text)
-	if len(parts) < 2 {
+	parts := strings.Fields(text)[1:]
+
+	// Template: "-t name" before the session name
+	var opts session.CreateOptions
+	if len(parts) > 0 && (parts[0] == "-t" || parts[0] == "--template") {
+		if len(parts) < 3 {
+			return nil
+		}
+		opts.Template = parts[1]
+		parts = parts[2:]
+		opts.Message = strings.Join(parts[1:], " ")
+	} else if len(parts) >= 2 {
+		opts.Dir = parts[1]
+	}
+	if len(parts) < 1 {
 		return nil
 	}
-	name := parts[1]
-	if !validName.MatchString(name) {
+	host, name := session.ParseLabel(parts[0])
+	if !session.ValidName(name) {
 		return nil
 	}
+	if host == "" && strings.Contains(parts[0], ":") {
+		host = "local" // ":name" overrides the template's host
+	}
+	opts.Name, opts.Host = name, host
 
-	dir := ""
-	if len(parts) >= 3 {
-		dir = parts[2]
+	executors, store := m.executors, m.store
+	return func() tea.Msg {
+		created, err := session.Create(executors, store, opts)
+		msg := sessionCreatedMsg{Name: name, Created: created, Err: err}
+		if err == nil {
+			msg.Host = created.Executor.HostName()
+		}
+		return msg
 	}
+}
 
+// sendInitialMessageCmd waits for a new session's prompt and sends it the
+// message it was created with.
+func sendInitialMessageCmd(name, host string, created session.Created) tea.Cmd {
 	return func() tea.Msg {
-		workDir := dir
-		if workDir == "" {
-			workDir, _ = os.Getwd()
-		} else if strings.HasPrefix(workDir, "~/") {
-			if home, err := os.UserHomeDir(); err == nil {
-				workDir = filepath.Join(home, workDir[2:])
-			}
+		ex, fullName := created.Executor, created.FullName
+		res := initialMessageSentMsg{Name: name, Host: host}
+		if res.Err = session.WaitForPrompt(ex, fullName); res.Err == nil {
+			res.Err = session.SendMessage(ex, fullName, created.Message)
 		}
+		return res
+	}
+}
 
-		fullName := tmux.SessionPrefix + name
-		if tmux.HasSession(fullName) {
-			return sessionCreatedMsg{Name: name, Err: fmt.Errorf("session %q already exists", name)}
+func (m Model) hasHost(host string) bool {
+	for _, e := range m.executors {
+		if e.HostName() == host {
+			return true
 		}
+	}
+	return false
+}
 
-		claudeArgs := []string{"--dangerously-skip-permissions"}
-		err := tmux.NewSession(name, workDir, claudeArgs)
-		return sessionCreatedMsg{Name: name, Err: err}
+// loadResumeSessionsCmd lists resumable sessions from the DB, skipping ones
+// still running. With all set, every recent Claude conversation on disk is
+// merged in, so sessions crabctl never managed can be adopted.
+func (m Model) loadResumeSessionsCmd(all bool) tea.Cmd {
+	store := m.store
+	executors := m.executors
+	return func() tea.Msg {
+		sessions, _ := session.LoadResumable(store, executors, all)
+		return claudeSessionsMsg(sessions)
 	}
 }
 
 func (m Model) handleResumeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Tab: toggle between crabctl sessions and all Claude conversations
+	if key.Matches(msg, keys.ResumeAll) {
+		m.resumeAll = !m.resumeAll
+		m.preview = nil
+		return m, m.loadResumeSessionsCmd(m.resumeAll)
+	}
+
 	// Navigation
 	navigateUp := func() {
 		if m.resumeCursor > 0 {
@@ -1075,25 +1271,42 @@ func (m Model) handleResumeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.preview == nil {
 			cs := *sel
 			m.preview = &previewState{
-				SessionName: strings.TrimPrefix(cs.Name, tmux.SessionPrefix),
+				SessionName: m.crabName(cs),
 				FullName:    cs.UUID,
+				Host:        cs.Host,
 			}
 			return m, m.resumePreviewCmd(cs)
 		}
 
-		// Stage 2: resume session
+		// Stage 2: resume session on the host it ran on (conversations
+		// never run as a crab are adopted under a name derived from
+		// their project dir)
 		cs := *sel
-		name := strings.TrimPrefix(cs.Name, tmux.SessionPrefix)
-		fullName := tmux.SessionPrefix + name
+		if cs.Host != "" && !m.hasHost(cs.Host) {
+			m.preview = nil
+			m.err = fmt.Errorf("host %q is no longer configured", cs.Host)
+			return m, nil
+		}
+		exec := m.findExecutor(cs.Host)
+		name := session.SuggestName(cs, exec.SessionPrefix())
+		fullName := exec.SessionPrefix() + name
+		host := cs.Host
+		store := m.store
 		m.pendingFocus = fullName
 		m.preview = nil
 		return m, func() tea.Msg {
-			if tmux.HasSession(fullName) {
-				return sessionCreatedMsg{Name: name, Err: fmt.Errorf("session %q already exists", name)}
+			if exec.HasSession(fullName) {
+				return sessionCreatedMsg{Name: name, Host: host, Err: fmt.Errorf("session %q already exists", name)}
+			}
+			claudeBin, claudeArgs, err := session.ResumeLaunchArgs(cs)
+			if err != nil {
+				return sessionCreatedMsg{Name: name, Host: host, Err: err}
+			}
+			err = exec.NewSession(name, cs.ProjectDir, claudeBin, claudeArgs)
+			if err == nil {
+				_ = store.RecordEvent(state.Event{Name: fullName, Host: host, Kind: state.EventResume, Detail: cs.UUID, WorkDir: cs.ProjectDir})
 			}
-			claudeArgs := []string{"--dangerously-skip-permissions", "--resume", cs.UUID}
-			err := tmux.NewSession(name, cs.ProjectDir, claudeArgs)
-			return sessionCreatedMsg{Name: name, Err: err}
+			return sessionCreatedMsg{Name: name, Host: host, Err: err}
 		}
 	}
 
@@ -1107,8 +1320,9 @@ func (m Model) handleResumeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) resumePreviewCmd(cs session.ClaudeSession) tea.Cmd {
 	workDir := cs.ProjectDir
 	uuid := cs.UUID
+	exec := m.findExecutor(cs.Host)
 	return func() tea.Msg {
-		output := session.ReadSessionPreview(workDir, uuid, 30)
+		output := session.ReadSessionPreview(exec, workDir, uuid, 30)
 		if output == "" {
 			output = "(no conversation found)"
 		}
@@ -1122,12 +1336,18 @@ func (m Model) switchResumePreview() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	cs := *sel
-	m.preview.SessionName = strings.TrimPrefix(cs.Name, tmux.SessionPrefix)
+	m.preview.SessionName = m.crabName(cs)
 	m.preview.FullName = cs.UUID
+	m.preview.Host = cs.Host
 	m.preview.Output = ""
 	return m, m.resumePreviewCmd(cs)
 }
 
+// crabName returns a past session's name without its host's session prefix.
+func (m Model) crabName(cs session.ClaudeSession) string {
+	return strings.TrimPrefix(cs.Name, m.findExecutor(cs.Host).SessionPrefix())
+}
+
 func (m *Model) applyResumeFilter() {
 	query := strings.TrimSpace(m.input.Value())
 	if query == "" {
@@ -1158,47 +1378,3 @@ func (m Model) selectedClaudeSession() *session.ClaudeSession {
 	cs := m.resumeFiltered[m.resumeCursor]
 	return &cs
 }
-
-// cleanPreviewOutput strips Claude's TUI decoration from captured pane output.
-func cleanPreviewOutput(output string) string {
-	lines := strings.Split(output, "\n")
-	var cleaned []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip empty lines at the start
-		if len(cleaned) == 0 && trimmed == "" {
-			continue
-		}
-
-		// Skip status bar lines
-		if strings.Contains(trimmed, "bypass permissions") ||
-			strings.Contains(trimmed, "shift+tab") ||
-			strings.Contains(trimmed, "auto-accept") ||
-			strings.Contains(trimmed, "plan mode") ||
-			strings.Contains(trimmed, "esc to interrupt") ||
-			strings.Contains(trimmed, "for shortcuts") {
-			continue
-		}
-
-		// Skip box-drawing borders (╭, ╰)
-		if strings.HasPrefix(trimmed, "╭") ||
-			strings.HasPrefix(trimmed, "╰") {
-			continue
-		}
-
-		// Skip pure horizontal rules
-		if trimmed != "" && strings.TrimLeft(trimmed, "─") == "" {
-			continue
-		}
-
-		cleaned = append(cleaned, line)
-	}
-
-	// Trim trailing empty lines
-	for len(cleaned) > 0 && strings.TrimSpace(cleaned[len(cleaned)-1]) == "" {
-		cleaned = cleaned[:len(cleaned)-1]
-	}
-
-	return strings.Join(cleaned, "\n")
-}
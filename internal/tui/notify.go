@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+// notifyFailedMsg reports a notifier that failed for a session.
+type notifyFailedMsg struct {
+	Label string
+	Err   error
+}
+
+// syncMutedFromDB reloads which sessions are muted, e.g. by `crabctl set`.
+func (m *Model) syncMutedFromDB() {
+	if m.store == nil {
+		return
+	}
+	if muted, err := m.store.LoadMuted(); err == nil {
+		m.muted = muted
+	}
+}
+
+// ToggleMute mutes or unmutes notifications for the given session.
+func (m *Model) ToggleMute(fullName, host, label string) {
+	key := state.QueueKey(host, fullName)
+	muted := !m.muted[key]
+	if muted {
+		m.muted[key] = true
+		m.notice = fmt.Sprintf("Muted notifications for %s", label)
+	} else {
+		delete(m.muted, key)
+		m.notice = fmt.Sprintf("Unmuted notifications for %s", label)
+	}
+	if m.store != nil {
+		_ = m.store.SetMuted(fullName, host, muted)
+	}
+}
+
+// notifyTransitions fires the configured notifiers for sessions that
+// changed to a notify status. Left to `crabctl daemon` while it runs.
+func (m Model) notifyTransitions(transitions []session.Transition) []tea.Cmd {
+	if m.daemonRunning || !m.notifier.Enabled() {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, t := range transitions {
+		if m.muted[state.QueueKey(t.Session.Host, t.Session.FullName)] || !m.notifier.Wants(t) {
+			continue
+		}
+		notifier := m.notifier
+		cmds = append(cmds, func() tea.Msg {
+			if err := notifier.Notify(t); err != nil {
+				return notifyFailedMsg{Label: t.Session.Label(), Err: err}
+			}
+			return nil
+		})
+	}
+	return cmds
+}
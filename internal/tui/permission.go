@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/tmux"
+)
+
+type permissionAnsweredMsg struct {
+	Name    string
+	Host    string
+	Summary string
+	Answer  session.PermissionAnswer
+	Err     error
+}
+
+// answerLabels names each answer for notices.
+var answerLabels = map[session.PermissionAnswer]string{
+	session.Approve: "approved",
+	session.Always:  "always allowed",
+	session.Deny:    "denied",
+}
+
+// handlePermissionKey answers the previewed session's permission prompt with
+// y/a/n, or approves every pending prompt for the same tool with A.
+// Returns false when the key isn't a permission key for this session.
+func (m Model) handlePermissionKey(msg tea.KeyMsg, sel *session.Session) (Model, tea.Cmd, bool) {
+	if sel == nil || sel.Prompt == nil || m.input.Value() != "" {
+		return m, nil, false
+	}
+
+	switch {
+	case key.Matches(msg, keys.Approve):
+		return m, m.answerPermissionCmd(*sel, session.Approve), true
+	case key.Matches(msg, keys.AlwaysAllow):
+		return m, m.answerPermissionCmd(*sel, session.Always), true
+	case key.Matches(msg, keys.Deny):
+		return m, m.answerPermissionCmd(*sel, session.Deny), true
+	case key.Matches(msg, keys.ApproveAll) && sel.Prompt.Tool != "":
+		var cmds []tea.Cmd
+		for _, s := range m.sessions {
+			if s.Prompt != nil && s.Prompt.Tool == sel.Prompt.Tool {
+				cmds = append(cmds, m.answerPermissionCmd(s, session.Approve))
+			}
+		}
+		m.notice = fmt.Sprintf("approving %d pending %s prompts...", len(cmds), sel.Prompt.Tool)
+		return m, tea.Batch(cmds...), true
+	}
+	return m, nil, false
+}
+
+// answerPermissionCmd re-reads the pane and, if it still shows the prompt
+// the user saw, presses the key for the answer. Checking first keeps a
+// stale list from answering a different prompt that replaced it.
+func (m Model) answerPermissionCmd(s session.Session, answer session.PermissionAnswer) tea.Cmd {
+	exec := m.findExecutor(s.Host)
+	want := s.Prompt.Summary()
+	return func() tea.Msg {
+		res := permissionAnsweredMsg{Name: s.Name, Host: s.Host, Summary: want, Answer: answer}
+		res.Err = answerPermission(exec, s.FullName, want, answer)
+		return res
+	}
+}
+
+// answerPermission presses the key for answer if the session's pane shows
+// the permission prompt summarized as want.
+func answerPermission(exec tmux.Executor, fullName, want string, answer session.PermissionAnswer) error {
+	output, err := exec.CapturePaneOutput(fullName, 50)
+	if err != nil {
+		return err
+	}
+	p := session.ParsePermissionPrompt(output)
+	if p == nil || p.Summary() != want {
+		return fmt.Errorf("prompt is no longer shown")
+	}
+	k := p.Key(answer)
+	if k == "" {
+		return fmt.Errorf("prompt has no %q option", answerLabels[answer])
+	}
+	return exec.SendKey(fullName, k)
+}
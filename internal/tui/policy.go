@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+type policyAppliedMsg struct {
+	FullName string
+	Decision state.PolicyDecision
+}
+
+// policyAnswers maps policy actions to the answer sent to the prompt.
+var policyAnswers = map[session.PolicyAction]session.PermissionAnswer{
+	session.PolicyAllow: session.Approve,
+	session.PolicyDeny:  session.Deny,
+}
+
+// checkPolicy answers the permission prompts the policy has a rule for.
+// A prompt is answered once; policyPending remembers it until the session
+// shows something else.
+func (m *Model) checkPolicy() []tea.Cmd {
+	if m.policy == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	active := make(map[string]bool)
+	for _, s := range m.sessions {
+		if s.Prompt == nil {
+			continue
+		}
+		summary := s.Prompt.Summary()
+		active[s.FullName] = true
+		if m.policyPending[s.FullName] == summary {
+			continue
+		}
+		action, rule := m.policy.Decide(s.Prompt)
+		if action == session.PolicyNone {
+			continue
+		}
+		m.policyPending[s.FullName] = summary
+		cmds = append(cmds, m.applyPolicyCmd(s, action, rule))
+	}
+	for fn := range m.policyPending {
+		if !active[fn] {
+			delete(m.policyPending, fn)
+		}
+	}
+	return cmds
+}
+
+// applyPolicyCmd answers a session's prompt as the policy decided and logs
+// the decision to the state DB.
+func (m Model) applyPolicyCmd(s session.Session, action session.PolicyAction, rule string) tea.Cmd {
+	exec := m.findExecutor(s.Host)
+	store := m.store
+	want := s.Prompt.Summary()
+	d := state.PolicyDecision{
+		Name:     s.Name,
+		Host:     s.Host,
+		Tool:     s.Prompt.Tool,
+		Argument: s.Prompt.Argument,
+		Action:   string(action),
+		Rule:     rule,
+	}
+	return func() tea.Msg {
+		if err := answerPermission(exec, s.FullName, want, policyAnswers[action]); err != nil {
+			d.Error = err.Error()
+		}
+		if store != nil {
+			_ = store.RecordPolicyDecision(d)
+		}
+		return policyAppliedMsg{FullName: s.FullName, Decision: d}
+	}
+}
+
+// policyNotice describes an applied decision for the help bar.
+func policyNotice(d state.PolicyDecision) string {
+	label := session.Label(d.Host, d.Name)
+	verb := "auto-approved"
+	if d.Action == string(session.PolicyDeny) {
+		verb = "auto-denied"
+	}
+	if d.Error != "" {
+		return fmt.Sprintf("%s: policy %s failed: %s", label, d.Action, d.Error)
+	}
+	return fmt.Sprintf("%s: %s %s (rule %s)", label, verb, (&session.PermissionPrompt{Tool: d.Tool, Argument: d.Argument}).Summary(), d.Rule)
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/state"
+)
+
+type queueDeliveredMsg struct {
+	Key     string
+	Label   string
+	Text    string
+	Skipped bool // session was no longer idle; try again later
+	Err     error
+}
+
+// syncQueueFromDB reloads queue depths, which `crabctl send --queue` may
+// have changed.
+func (m *Model) syncQueueFromDB() {
+	if m.store == nil {
+		return
+	}
+	if depths, err := m.store.QueueDepths(); err == nil {
+		m.queueDepth = depths
+	}
+}
+
+// enqueue queues text for the previewed session.
+func (m *Model) enqueue(text string) {
+	if m.store == nil {
+		m.notice = "queueing needs the state database"
+		return
+	}
+	n, err := m.store.EnqueueMessage(m.preview.FullName, m.preview.Host, text)
+	if err != nil {
+		m.notice = fmt.Sprintf("queue failed: %v", err)
+		return
+	}
+	m.queueDepth[state.QueueKey(m.preview.Host, m.preview.FullName)] = n
+	m.notice = fmt.Sprintf("queued for %s (%d pending)", m.preview.SessionName, n)
+}
+
+// checkQueue sends the next queued message to each idle session that is
+// due one. Left to `crabctl daemon` while it runs.
+func (m *Model) checkQueue() []tea.Cmd {
+	if m.store == nil || m.daemonRunning {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, s := range m.queue.Due(m.sessions, m.queueDepth, time.Now()) {
+		cmds = append(cmds, m.deliverQueuedCmd(s))
+	}
+	return cmds
+}
+
+// deliverQueuedCmd sends the oldest queued message of s if it is still idle.
+func (m Model) deliverQueuedCmd(s session.Session) tea.Cmd {
+	exec := m.findExecutor(s.Host)
+	store := m.store
+	key := state.QueueKey(s.Host, s.FullName)
+	fullName, host := s.FullName, s.Host
+	label := session.Label(host, s.Name)
+	return func() tea.Msg {
+		res := queueDeliveredMsg{Key: key, Label: label}
+		msg, sent, err := session.DeliverQueued(exec, store, fullName, host)
+		res.Text, res.Err = msg.Text, err
+		res.Skipped = !sent && err == nil
+		return res
+	}
+}
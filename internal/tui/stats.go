@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/simon/crabctl/internal/session"
+)
+
+// statsWindows are the lookbacks the stats panel cycles through.
+var statsWindows = []struct {
+	Label string
+	Since time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// statsLoadedMsg carries the per-session stats for the stats panel.
+type statsLoadedMsg struct {
+	Window int
+	Stats  []session.SessionStats
+	Err    error
+}
+
+// statsState is the full-screen time-in-status report opened with /stats.
+type statsState struct {
+	Window int // index into statsWindows
+	ByRepo bool
+	Stats  []session.SessionStats
+	Loaded bool
+	Err    error
+	Offset int // first visible row
+}
+
+// openStats opens the stats panel over the last 7 days.
+func (m Model) openStats() (tea.Model, tea.Cmd) {
+	m.input.SetValue("")
+	m.stats = &statsState{Window: 1}
+	if m.store == nil {
+		m.stats.Loaded = true
+		m.stats.Err = errors.New("no state database")
+		return m, nil
+	}
+	return m, m.loadStatsCmd(m.stats.Window)
+}
+
+func (m Model) loadStatsCmd(window int) tea.Cmd {
+	store := m.store
+	live := m.sessions
+	return func() tea.Msg {
+		// Statuses entered before the window still count from its start
+		events, err := store.ListEvents("", "", time.Time{})
+		now := time.Now()
+		stats := session.ComputeStats(events, live, now.Add(-statsWindows[window].Since), now)
+		return statsLoadedMsg{Window: window, Stats: stats, Err: err}
+	}
+}
+
+func (m *Model) setStats(msg statsLoadedMsg) {
+	if m.stats == nil || m.stats.Window != msg.Window {
+		return
+	}
+	m.stats.Stats, m.stats.Err, m.stats.Loaded = msg.Stats, msg.Err, true
+}
+
+// statsGroups returns the rows of the panel, by session or by repo.
+func (m Model) statsGroups() []session.GroupStats {
+	return session.GroupStatsBy(m.stats.Stats, func(s session.SessionStats) string {
+		if m.stats.ByRepo {
+			return session.Label(s.Host, shortenPath(s.Repo(), 40))
+		}
+		return session.Label(s.Host, strings.TrimPrefix(s.Name, m.findExecutor(s.Host).SessionPrefix()))
+	})
+}
+
+// statsHeight is the number of rows that fit on screen.
+// Budget: title+blank(2) + border+header(2) + border(1) + help(1) + safety(1)
+func (m Model) statsHeight() int {
+	return max(3, m.height-7)
+}
+
+func (m Model) handleStatsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.stats
+	switch {
+	case key.Matches(msg, keys.StatsGroup):
+		st.ByRepo = !st.ByRepo
+		st.Offset = 0
+	case key.Matches(msg, keys.StatsWindow):
+		st.Window = (st.Window + 1) % len(statsWindows)
+		st.Loaded = false
+		st.Offset = 0
+		return m, m.loadStatsCmd(st.Window)
+	case key.Matches(msg, keys.Up):
+		st.Offset--
+	case key.Matches(msg, keys.Down):
+		st.Offset++
+	case key.Matches(msg, keys.PageUp):
+		st.Offset -= m.statsHeight() - 1
+	case key.Matches(msg, keys.PageDown):
+		st.Offset += m.statsHeight() - 1
+	}
+	st.Offset = max(0, min(st.Offset, len(m.statsGroups())-m.statsHeight()))
+	return m, nil
+}
+
+func (m Model) renderStats(b *strings.Builder) {
+	st := m.stats
+	by := "session"
+	if st.ByRepo {
+		by = "repo"
+	}
+	borderTitle := fmt.Sprintf(" ─── stats: last %s by %s ", statsWindows[st.Window].Label, by)
+	if remaining := m.width - lipgloss.Width(borderTitle) - 2; remaining > 0 {
+		borderTitle += strings.Repeat("─", remaining)
+	}
+	b.WriteString(previewBorderStyle.Render(" " + borderTitle))
+	b.WriteString("\n")
+
+	groups := m.statsGroups()
+	switch {
+	case !st.Loaded:
+		b.WriteString(previewContentStyle.Render(" Loading..."))
+		b.WriteString("\n")
+	case st.Err != nil:
+		b.WriteString(previewContentStyle.Render(" Error: " + st.Err.Error()))
+		b.WriteString("\n")
+	case len(groups) == 0:
+		b.WriteString(previewContentStyle.Render(" No history in this window."))
+		b.WriteString("\n")
+	default:
+		keyWidth := len(by)
+		for _, g := range groups {
+			keyWidth = max(keyWidth, lipgloss.Width(g.Key))
+		}
+		widths := []int{keyWidth, 9, 9, 9, 11, 8, 8, 5, 5, 9}
+		row := func(cells ...string) string {
+			var line strings.Builder
+			for i, c := range cells {
+				line.WriteString(pad(c, widths[i]) + " ")
+			}
+			return line.String()
+		}
+		b.WriteString(headerStyle.Render(row(strings.ToUpper(by), "SESSIONS", "RUNNING", "WAITING", "PERMISSION", "STALLED", "AUTOFWD", "SENT", "DONE", "TO DONE")))
+		b.WriteString("\n")
+		end := min(st.Offset+m.statsHeight(), len(groups))
+		for _, g := range groups[st.Offset:end] {
+			toDone := "-"
+			if g.Done > 0 {
+				toDone = session.FormatDuration(g.AvgToDone())
+			}
+			b.WriteString(" " + previewContentStyle.Render(row(
+				g.Key,
+				fmt.Sprint(g.Sessions),
+				session.FormatDuration(g.Running),
+				session.FormatDuration(g.Waiting),
+				session.FormatDuration(g.Blocked),
+				fmt.Sprintf("%d%%", g.StalledPercent()),
+				fmt.Sprint(g.AutoForwards),
+				fmt.Sprint(g.Sends),
+				fmt.Sprint(g.Done),
+				toDone,
+			)))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(previewBorderStyle.Render(" " + strings.Repeat("─", max(0, m.width-2))))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("tab session/repo  w 24h/7d/30d  j/k scroll  esc close"))
+	b.WriteString("\n")
+}
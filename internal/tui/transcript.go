@@ -0,0 +1,337 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/simon/crabctl/internal/session"
+)
+
+var (
+	transcriptUserStyle = lipgloss.NewStyle().
+				Foreground(accentColor).
+				Bold(true)
+
+	transcriptMatchStyle = lipgloss.NewStyle().
+				Background(yellowColor).
+				Foreground(lipgloss.AdaptiveColor{Light: "#000000", Dark: "#000000"})
+)
+
+type transcriptLoadedMsg struct {
+	FullName string
+	Entries  []session.TranscriptEntry
+	Err      error
+}
+
+// transcriptState is the full-screen transcript viewer for one session.
+type transcriptState struct {
+	SessionName string
+	FullName    string
+	UUID        string
+	Entries     []session.TranscriptEntry
+	Loaded      bool
+	Err         error
+	Expanded    bool // show full tool inputs and results
+	Offset      int  // first visible line
+	Searching   bool // typing a search query into the input
+	Query       string
+	Matches     []int // indices of lines matching Query
+	Match       int   // current index into Matches
+	Notice      string
+	lines       []transcriptLine
+}
+
+type transcriptLine struct {
+	text  string
+	style lipgloss.Style
+}
+
+func (m Model) loadTranscriptCmd(sel session.Session) tea.Cmd {
+	fsys := m.findExecutor(sel.Host)
+	return func() tea.Msg {
+		entries, err := session.ReadTranscript(fsys, sel.WorkDir, sel.SessionUUID)
+		return transcriptLoadedMsg{FullName: sel.FullName, Entries: entries, Err: err}
+	}
+}
+
+// openTranscript opens the transcript viewer for the selected session.
+func (m Model) openTranscript() (tea.Model, tea.Cmd) {
+	sel := m.selectedSession()
+	if sel == nil {
+		return m, nil
+	}
+	m.transcript = &transcriptState{
+		SessionName: sel.Name,
+		FullName:    sel.FullName,
+		UUID:        sel.SessionUUID,
+	}
+	if sel.SessionUUID == "" {
+		m.transcript.Loaded = true
+		m.transcript.Err = fmt.Errorf("no Claude session file matched yet")
+		return m, nil
+	}
+	m.input.SetValue("")
+	return m, m.loadTranscriptCmd(*sel)
+}
+
+// transcriptHeight is the number of transcript lines that fit on screen.
+// Budget: title+blank(2) + borders(2) + input(1) + help(1) + safety(1)
+func (m Model) transcriptHeight() int {
+	return max(3, m.height-7)
+}
+
+// layoutTranscript re-wraps the transcript for the current width and
+// expansion, keeping search matches in sync.
+func (m *Model) layoutTranscript() {
+	t := m.transcript
+	width := max(20, m.width-3)
+	t.lines = nil
+
+	// add wraps text under a marker like "⏺ ", indenting continuation lines
+	add := func(text, marker string, style lipgloss.Style) {
+		cont := strings.Repeat(" ", lipgloss.Width(marker))
+		for i, l := range strings.Split(ansi.Wrap(text, width-len(cont), ""), "\n") {
+			prefix := marker
+			if i > 0 {
+				prefix = cont
+			}
+			t.lines = append(t.lines, transcriptLine{text: prefix + l, style: style})
+		}
+	}
+	for i, e := range t.Entries {
+		switch e.Kind {
+		case session.EntryUser:
+			if i > 0 {
+				t.lines = append(t.lines, transcriptLine{})
+			}
+			add(e.Text, "❯ ", transcriptUserStyle)
+		case session.EntryAssistant:
+			if i > 0 {
+				t.lines = append(t.lines, transcriptLine{})
+			}
+			add(e.Text, "", previewContentStyle)
+		case session.EntryToolCall:
+			add(e.Summary, "⏺ ", modeStyle)
+			if t.Expanded {
+				add(e.Text, "    ", actionStyle)
+			}
+		case session.EntryToolResult:
+			style := actionStyle
+			if e.IsError {
+				style = statusPermission
+			}
+			if t.Expanded {
+				add(e.Text, "  ⎿ ", style)
+			} else {
+				add(e.Summary, "  ⎿ ", style)
+			}
+		}
+	}
+	m.findTranscriptMatches()
+	m.clampTranscriptOffset()
+}
+
+func (m *Model) findTranscriptMatches() {
+	t := m.transcript
+	t.Matches = nil
+	t.Match = 0
+	if t.Query == "" {
+		return
+	}
+	q := strings.ToLower(t.Query)
+	for i, l := range t.lines {
+		if strings.Contains(strings.ToLower(l.text), q) {
+			t.Matches = append(t.Matches, i)
+		}
+	}
+}
+
+func (m *Model) clampTranscriptOffset() {
+	t := m.transcript
+	t.Offset = min(t.Offset, len(t.lines)-m.transcriptHeight())
+	t.Offset = max(t.Offset, 0)
+}
+
+// jumpToMatch scrolls so the current match is visible.
+func (m *Model) jumpToMatch() {
+	t := m.transcript
+	if len(t.Matches) == 0 {
+		t.Notice = fmt.Sprintf("no match for %q", t.Query)
+		return
+	}
+	line := t.Matches[t.Match]
+	h := m.transcriptHeight()
+	if line < t.Offset || line >= t.Offset+h {
+		t.Offset = line - h/2
+		m.clampTranscriptOffset()
+	}
+	t.Notice = fmt.Sprintf("match %d/%d", t.Match+1, len(t.Matches))
+}
+
+func (m Model) handleTranscriptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.transcript
+	t.Notice = ""
+
+	if t.Searching {
+		if key.Matches(msg, keys.Enter) {
+			t.Searching = false
+			t.Query = strings.TrimSpace(m.input.Value())
+			m.input.SetValue("")
+			m.findTranscriptMatches()
+			// Start at the first match below the top of the screen
+			for i, line := range t.Matches {
+				if line >= t.Offset {
+					t.Match = i
+					break
+				}
+			}
+			if t.Query != "" {
+				m.jumpToMatch()
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	h := m.transcriptHeight()
+	switch {
+	case key.Matches(msg, keys.Up):
+		t.Offset--
+	case key.Matches(msg, keys.Down):
+		t.Offset++
+	case key.Matches(msg, keys.PageUp):
+		t.Offset -= h - 1
+	case key.Matches(msg, keys.PageDown):
+		t.Offset += h - 1
+	case key.Matches(msg, keys.Top):
+		t.Offset = 0
+	case key.Matches(msg, keys.Bottom):
+		t.Offset = len(t.lines)
+	case key.Matches(msg, keys.Search):
+		t.Searching = true
+		m.input.SetValue("")
+		return m, nil
+	case key.Matches(msg, keys.NextMatch), key.Matches(msg, keys.PrevMatch):
+		if len(t.Matches) > 0 {
+			step := 1
+			if key.Matches(msg, keys.PrevMatch) {
+				step = len(t.Matches) - 1
+			}
+			t.Match = (t.Match + step) % len(t.Matches)
+		}
+		m.jumpToMatch()
+		return m, nil
+	case key.Matches(msg, keys.ToggleTools):
+		// Keep the line at the top of the screen roughly in place
+		frac := 0.0
+		if len(t.lines) > 0 {
+			frac = float64(t.Offset) / float64(len(t.lines))
+		}
+		t.Expanded = !t.Expanded
+		m.layoutTranscript()
+		t.Offset = int(frac * float64(len(t.lines)))
+	case key.Matches(msg, keys.Export):
+		path, err := m.exportTranscript()
+		if err != nil {
+			t.Notice = "export failed: " + err.Error()
+		} else {
+			t.Notice = "exported to " + path
+		}
+		return m, nil
+	}
+	m.clampTranscriptOffset()
+	return m, nil
+}
+
+// exportTranscript writes the transcript as Markdown to the current directory.
+func (m Model) exportTranscript() (string, error) {
+	t := m.transcript
+	if len(t.Entries) == 0 {
+		return "", fmt.Errorf("nothing to export")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	short := t.UUID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.md", t.SessionName, short))
+	md := session.TranscriptMarkdown(t.SessionName, t.Entries)
+	return path, os.WriteFile(path, []byte(md), 0o644)
+}
+
+// renderTranscript renders the transcript pane with its input and help lines.
+func (m Model) renderTranscript(b *strings.Builder) {
+	t := m.transcript
+	borderTitle := fmt.Sprintf(" ─── transcript: %s ", t.SessionName)
+	if t.Query != "" {
+		borderTitle += fmt.Sprintf("─ /%s ", t.Query)
+	}
+	remaining := m.width - lipgloss.Width(borderTitle) - 2
+	if remaining > 0 {
+		borderTitle += strings.Repeat("─", remaining)
+	}
+	b.WriteString(previewBorderStyle.Render(" " + borderTitle))
+	b.WriteString("\n")
+
+	h := m.transcriptHeight()
+	switch {
+	case !t.Loaded:
+		b.WriteString(previewContentStyle.Render(" Loading..."))
+		b.WriteString("\n")
+	case t.Err != nil:
+		b.WriteString(previewContentStyle.Render(" Error: " + t.Err.Error()))
+		b.WriteString("\n")
+	default:
+		current := -1
+		if len(t.Matches) > 0 {
+			current = t.Matches[t.Match]
+		}
+		end := min(t.Offset+h, len(t.lines))
+		for i := t.Offset; i < end; i++ {
+			style := t.lines[i].style
+			if i == current {
+				style = transcriptMatchStyle
+			}
+			b.WriteString(" " + style.Render(t.lines[i].text))
+			b.WriteString("\n")
+		}
+	}
+
+	bottom := strings.Repeat("─", max(0, m.width-2))
+	if t.Loaded && len(t.lines) > h {
+		pos := fmt.Sprintf(" %d%% ", 100*min(t.Offset+h, len(t.lines))/len(t.lines))
+		bottom = strings.Repeat("─", max(0, m.width-2-len(pos)-3)) + pos + "───"
+	}
+	b.WriteString(previewBorderStyle.Render(" " + bottom))
+	b.WriteString("\n")
+
+	// Input line doubles as search prompt and notice area
+	if t.Searching {
+		m.input.Placeholder = "Search transcript..."
+		b.WriteString(inputLabelStyle.Render(" / "))
+		b.WriteString(m.input.View())
+	} else {
+		b.WriteString(inputLabelStyle.Render(" > "))
+		b.WriteString(helpStyle.Render(t.Notice))
+	}
+	b.WriteString("\n")
+
+	if t.Searching {
+		b.WriteString(helpStyle.Render("enter search  esc cancel"))
+	} else {
+		b.WriteString(helpStyle.Render("j/k scroll  pgup/pgdn page  g/G top/bottom  / search  n/N next/prev  t tools  e export  esc close"))
+	}
+	b.WriteString("\n")
+}
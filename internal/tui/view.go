@@ -11,7 +11,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/simon/crabctl/internal/session"
-	"github.com/simon/crabctl/internal/tmux"
+	"github.com/simon/crabctl/internal/state"
 )
 
 var (
@@ -122,8 +122,31 @@ func (m Model) View() string {
 
 	// Title
 	b.WriteString(titleStyle.Render("crabctl"))
+	if m.daemonRunning {
+		b.WriteString(helpStyle.Render("daemon running"))
+	}
 	b.WriteString("\n\n")
 
+	if m.afDialog != nil {
+		m.renderAutoForwardDialog(&b)
+		return b.String()
+	}
+
+	if m.history != nil {
+		m.renderHistory(&b)
+		return b.String()
+	}
+
+	if m.stats != nil {
+		m.renderStats(&b)
+		return b.String()
+	}
+
+	if m.transcript != nil {
+		m.renderTranscript(&b)
+		return b.String()
+	}
+
 	if m.resumeMode {
 		m.renderResumeList(&b, m.preview != nil)
 	} else if len(m.sessions) == 0 && m.err == nil {
@@ -143,7 +166,7 @@ func (m Model) View() string {
 
 		// Precompute cell values for visible rows
 		type rowData struct {
-			host, name, dir, status, mode, info, changes string
+			host, name, dir, status, mode, info, cost, changes string
 		}
 		rows := make([]rowData, 0, end-m.scrollOffset)
 		for i := m.scrollOffset; i < end; i++ {
@@ -156,17 +179,31 @@ func (m Model) View() string {
 			if host == "" && showHost {
 				host = "local"
 			}
-			mode := s.Mode
-			if m.autoForward[s.FullName] {
-				mode = "autoforward"
+			mode := renderMode(s.Mode)
+			if m.autoForward.Enabled(s.FullName, s.Host) {
+				// The daemon keeps its own count
+				label := "autofwd"
+				if !m.daemonRunning {
+					left, limit := m.autoForward.Remaining(s.FullName, s.Host)
+					label = fmt.Sprintf("autofwd %d/%d", left, limit)
+				}
+				mode = statusPermission.Render(label)
+			}
+			dir := shortenPath(s.WorkDir, 20)
+			if branch := s.Branch; branch != "" {
+				if len(branch) > 18 {
+					branch = branch[:17] + "…"
+				}
+				dir = "⎇ " + branch
 			}
 			rows = append(rows, rowData{
 				host:    host,
 				name:    name,
-				dir:     shortenPath(s.WorkDir, 20),
+				dir:     dir,
 				status:  renderStatusWithAge(s),
-				mode:    renderMode(mode),
-				info:    renderInfo(s),
+				mode:    mode,
+				info:    renderInfo(s, m.queueDepth[state.QueueKey(s.Host, s.FullName)], m.muted[state.QueueKey(s.Host, s.FullName)]),
+				cost:    renderCost(s),
 				changes: renderChanges(s),
 			})
 		}
@@ -182,12 +219,13 @@ func (m Model) View() string {
 			{min: 7, max: 14, header: "STATUS"},
 			{min: 4, max: 12, header: "MODE"},
 			{min: 4, max: 40, header: "INFO"},
+			{min: 4, max: 8, header: "COST"},
 		}
 		hostCol := colSpec{min: 4, max: 10, header: "HOST"}
 
 		// Measure from data
 		for _, r := range rows {
-			vals := []string{r.name, r.dir, r.status, r.mode, r.info}
+			vals := []string{r.name, r.dir, r.status, r.mode, r.info, r.cost}
 			for j, v := range vals {
 				w := lipgloss.Width(v)
 				if w > cols[j].width {
@@ -227,14 +265,14 @@ func (m Model) View() string {
 			}
 		}
 
-		wName, wDir, wStatus, wMode, wInfo := cols[0].width, cols[1].width, cols[2].width, cols[3].width, cols[4].width
+		wName, wDir, wStatus, wMode, wInfo, wCost := cols[0].width, cols[1].width, cols[2].width, cols[3].width, cols[4].width, cols[5].width
 
 		// Render header
 		if showHost {
-			header := "    " + pad("HOST", hostCol.width) + "  " + pad("NAME", wName) + "  " + pad("DIR", wDir) + "  " + pad("STATUS", wStatus) + "  " + pad("MODE", wMode) + "  " + pad("INFO", wInfo) + "  CHANGES"
+			header := "    " + pad("HOST", hostCol.width) + "  " + pad("NAME", wName) + "  " + pad("DIR", wDir) + "  " + pad("STATUS", wStatus) + "  " + pad("MODE", wMode) + "  " + pad("INFO", wInfo) + "  " + pad("COST", wCost) + "  CHANGES"
 			b.WriteString(headerStyle.Render(header))
 		} else {
-			header := "    " + pad("NAME", wName) + "  " + pad("DIR", wDir) + "  " + pad("STATUS", wStatus) + "  " + pad("MODE", wMode) + "  " + pad("INFO", wInfo) + "  CHANGES"
+			header := "    " + pad("NAME", wName) + "  " + pad("DIR", wDir) + "  " + pad("STATUS", wStatus) + "  " + pad("MODE", wMode) + "  " + pad("INFO", wInfo) + "  " + pad("COST", wCost) + "  CHANGES"
 			b.WriteString(headerStyle.Render(header))
 		}
 		b.WriteString("\n")
@@ -252,9 +290,9 @@ func (m Model) View() string {
 			i := m.scrollOffset + ri
 			var row string
 			if showHost {
-				row = " " + pad(r.host, hostCol.width) + "  " + pad(r.name, wName) + "  " + pad(r.dir, wDir) + "  " + pad(r.status, wStatus) + "  " + pad(r.mode, wMode) + "  " + pad(r.info, wInfo) + "  " + r.changes
+				row = " " + pad(r.host, hostCol.width) + "  " + pad(r.name, wName) + "  " + pad(r.dir, wDir) + "  " + pad(r.status, wStatus) + "  " + pad(r.mode, wMode) + "  " + pad(r.info, wInfo) + "  " + pad(r.cost, wCost) + "  " + r.changes
 			} else {
-				row = " " + pad(r.name, wName) + "  " + pad(r.dir, wDir) + "  " + pad(r.status, wStatus) + "  " + pad(r.mode, wMode) + "  " + pad(r.info, wInfo) + "  " + r.changes
+				row = " " + pad(r.name, wName) + "  " + pad(r.dir, wDir) + "  " + pad(r.status, wStatus) + "  " + pad(r.mode, wMode) + "  " + pad(r.info, wInfo) + "  " + pad(r.cost, wCost) + "  " + r.changes
 			}
 
 			if i == m.cursor {
@@ -351,7 +389,11 @@ func (m Model) View() string {
 			if len(m.remoteLoading) > 0 {
 				loadingLine = 1
 			}
-			overhead := 9 + visibleRows + scrollIndicators + loadingLine
+			menuLines := 0
+			if m.preview.Menu != nil {
+				menuLines = len(m.preview.Menu.Options)
+			}
+			overhead := 9 + visibleRows + scrollIndicators + loadingLine + menuLines
 			maxPreview := m.height - overhead
 			if maxPreview < 3 {
 				maxPreview = 3
@@ -366,6 +408,9 @@ func (m Model) View() string {
 				b.WriteString(previewContentStyle.Render(" " + line))
 				b.WriteString("\n")
 			}
+			if m.preview.Menu != nil {
+				m.renderMenu(&b)
+			}
 		} else {
 			b.WriteString(previewContentStyle.Render(" Loading..."))
 			b.WriteString("\n")
@@ -401,18 +446,46 @@ func (m Model) View() string {
 		b.WriteString("  ")
 		b.WriteString(confirmKeyStyle.Render("Esc"))
 		b.WriteString(confirmDimStyle.Render("cancel"))
+	} else if m.confirmWorktree != nil {
+		b.WriteString(confirmLabelStyle.Render(fmt.Sprintf("Remove worktree %s? (branch %s is kept)", m.confirmWorktree.Path, m.confirmWorktree.Branch)))
+		b.WriteString("  ")
+		b.WriteString(confirmKeyStyle.Render("Enter"))
+		b.WriteString(confirmDimStyle.Render("remove"))
+		b.WriteString("  ")
+		b.WriteString(confirmKeyStyle.Render("Esc"))
+		b.WriteString(confirmDimStyle.Render("keep"))
+	} else if m.notice != "" {
+		b.WriteString(helpStyle.Render(m.notice))
+	} else if sel := m.selectedSession(); sel != nil && sel.Prompt != nil && !m.resumeMode && m.input.Value() == "" {
+		b.WriteString(statusPermission.Render(" " + sel.Prompt.Summary()))
+		help := "y approve  a always  n deny"
+		if sel.Prompt.Tool != "" {
+			help += "  A approve all " + sel.Prompt.Tool
+		}
+		if m.preview == nil {
+			help = "enter preview to answer"
+		}
+		b.WriteString(helpStyle.Render(help))
 	} else if m.resumeMode && m.preview != nil {
-		b.WriteString(helpStyle.Render("enter resume  j/k navigate  esc close preview"))
+		b.WriteString(helpStyle.Render("enter resume  j/k navigate  tab all/crabs  esc close preview"))
 	} else if m.resumeMode {
-		b.WriteString(helpStyle.Render("enter preview  type to filter  j/k navigate  esc back"))
+		b.WriteString(helpStyle.Render("enter preview  type to filter  j/k navigate  tab all/crabs  esc back"))
+	} else if m.preview != nil && m.preview.Menu != nil && m.input.Value() == "" {
+		help := fmt.Sprintf("1-%d pick option", len(m.preview.Menu.Options))
+		if m.preview.Menu.TextOption() != nil {
+			help += "  type+enter answer"
+		}
+		b.WriteString(helpStyle.Render(help + "  enter attach  esc close  j/k navigate"))
 	} else if m.preview != nil {
-		b.WriteString(helpStyle.Render("enter attach  type+enter send  esc close  j/k navigate  ctrl+a autoforward  ctrl+k kill"))
+		b.WriteString(helpStyle.Render("enter attach  type+enter send  type+ctrl+q queue  esc close  j/k navigate  ctrl+t transcript  ctrl+g history  ctrl+a autoforward  ctrl+o af settings  ctrl+x mute  ctrl+k kill"))
 	} else if strings.HasPrefix(m.input.Value(), "/new") {
-		b.WriteString(helpStyle.Render("/new <name> [dir]  —  create a new session"))
+		b.WriteString(helpStyle.Render("/new [host:]<name> [dir]  /new -t <template> [host:]<name> [text]  —  create a new session"))
 	} else if strings.HasPrefix(m.input.Value(), "/resume") {
-		b.WriteString(helpStyle.Render("/resume  —  browse and resume past Claude sessions"))
+		b.WriteString(helpStyle.Render("/resume [all]  —  browse and resume past Claude sessions"))
+	} else if strings.HasPrefix(m.input.Value(), "/stats") {
+		b.WriteString(helpStyle.Render("/stats  —  time running, waiting and blocked per session or repo"))
 	} else {
-		b.WriteString(helpStyle.Render("enter preview  /new  /resume  j/k navigate  ctrl+a autoforward  ctrl+k kill  q quit"))
+		b.WriteString(helpStyle.Render("enter preview  /new  /resume  /stats  j/k navigate  ctrl+t transcript  ctrl+g history  ctrl+a autoforward  ctrl+o af settings  ctrl+x mute  ctrl+k kill  q quit"))
 	}
 	b.WriteString("\n")
 
@@ -420,7 +493,11 @@ func (m Model) View() string {
 }
 
 func (m Model) renderResumeList(b *strings.Builder, showPreview bool) {
-	b.WriteString(headerStyle.Render("  Resume a session"))
+	if m.resumeAll {
+		b.WriteString(headerStyle.Render("  Resume a session (all Claude conversations)"))
+	} else {
+		b.WriteString(headerStyle.Render("  Resume a session"))
+	}
 	b.WriteString("\n\n")
 
 	if len(m.resumeFiltered) == 0 {
@@ -445,10 +522,14 @@ func (m Model) renderResumeList(b *strings.Builder, showPreview bool) {
 	for i := start; i < end; i++ {
 		cs := m.resumeFiltered[i]
 		age := session.FormatDuration(time.Since(cs.ModTime))
-		if !cs.Killed {
+		if cs.Tracked && !cs.Killed {
 			age += " ~"
 		}
-		name := strings.TrimPrefix(cs.Name, tmux.SessionPrefix)
+		name := m.crabName(cs)
+		name = session.Label(cs.Host, name)
+		if !cs.Tracked {
+			name = "+ new crab"
+		}
 		if len(name) > 16 {
 			name = name[:13] + "..."
 		}
@@ -520,9 +601,6 @@ func renderMode(mode string) string {
 	if mode == "" {
 		return statusUnknown.Render("-")
 	}
-	if mode == "autoforward" {
-		return statusPermission.Render("autoforward")
-	}
 	return modeStyle.Render(mode)
 }
 
@@ -533,10 +611,20 @@ func renderAction(action string) string {
 	return actionStyle.Render(action)
 }
 
-func renderInfo(s session.Session) string {
+func renderInfo(s session.Session, queued int, muted bool) string {
 	var parts []string
 
-	if s.LastAction != "" {
+	if muted {
+		parts = append(parts, statusUnknown.Render("muted"))
+	}
+
+	if queued > 0 {
+		parts = append(parts, modeStyle.Render(fmt.Sprintf("queued:%d", queued)))
+	}
+
+	if s.Prompt != nil {
+		parts = append(parts, statusPermission.Render(s.Prompt.Summary()))
+	} else if s.LastAction != "" {
 		parts = append(parts, actionStyle.Render(s.LastAction))
 	}
 	if s.Context != "" {
@@ -546,6 +634,13 @@ func renderInfo(s session.Session) string {
 	return strings.Join(parts, actionStyle.Render(" · "))
 }
 
+func renderCost(s session.Session) string {
+	if s.Usage.Messages == 0 {
+		return ""
+	}
+	return actionStyle.Render(session.FormatCost(s.Usage.Cost))
+}
+
 func renderChanges(s session.Session) string {
 	var parts []string
 
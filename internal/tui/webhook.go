@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simon/crabctl/internal/session"
+	"github.com/simon/crabctl/internal/webhook"
+)
+
+// webhookFailedMsg reports a webhook delivery that failed after retries.
+type webhookFailedMsg struct {
+	Err error
+}
+
+// webhookEvents returns the webhook events for the changes between two
+// listings of a host, as returned by recordListing: sessions that appeared
+// or went, and status changes. Left to `crabctl daemon` while it runs.
+func (m Model) webhookEvents(appeared, gone []session.Session, transitions []session.Transition) []webhook.Event {
+	if m.daemonRunning || !m.webhooks.Enabled() {
+		return nil
+	}
+	now := time.Now()
+	var events []webhook.Event
+	for _, s := range appeared {
+		events = append(events, webhook.Created(s, now))
+	}
+	for _, s := range gone {
+		events = append(events, webhook.Killed(s, now))
+	}
+	for _, t := range transitions {
+		events = append(events, webhook.Transition(t, now)...)
+	}
+	return events
+}
+
+// sendWebhooks queues events to be sent in order in the background. Left
+// to `crabctl daemon` while it runs.
+func (m Model) sendWebhooks(events []webhook.Event) {
+	if m.daemonRunning {
+		return
+	}
+	m.webhookQueue.Add(events...)
+}
+
+// waitWebhookErr waits for the next failed delivery. The handler of the
+// returned message waits again.
+func waitWebhookErr(errs <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		return webhookFailedMsg{Err: <-errs}
+	}
+}
@@ -0,0 +1,318 @@
+// Package webhook posts session events as JSON to the targets configured
+// under "webhooks", signing them and retrying failed deliveries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+)
+
+// Event types.
+const (
+	SessionCreated      = "session_created"
+	SessionKilled       = "session_killed" // killed, or Claude exited
+	StatusChanged       = "status_changed"
+	AutoForwardSent     = "autoforward_sent"
+	TaskDone            = "task_done"
+	PermissionRequested = "permission_requested"
+	Test                = "test" // sent by `crabctl webhook test`, whatever the target's events
+)
+
+// EventTypes are the event types a target can subscribe to.
+var EventTypes = []string{SessionCreated, SessionKilled, StatusChanged, AutoForwardSent, TaskDone, PermissionRequested}
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 10 * time.Second
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a ".", and the body, keyed with the target's secret.
+	SignatureHeader = "X-Crabctl-Signature"
+	// TimestampHeader carries the Unix time of the delivery attempt, so
+	// receivers can reject old deliveries replayed with a valid signature.
+	TimestampHeader = "X-Crabctl-Timestamp"
+)
+
+// Event is the JSON body posted to webhooks. Text is a one-line summary,
+// which is also what Slack-style incoming webhooks display.
+type Event struct {
+	Event    string        `json:"event"`
+	Time     time.Time     `json:"time"`
+	Text     string        `json:"text"`
+	Session  session.Entry `json:"session"`
+	Previous string        `json:"previous,omitempty"` // status before a status change
+	Prompt   string        `json:"prompt,omitempty"`   // pending permission prompt
+}
+
+// Created returns the event for a session that appeared.
+func Created(s session.Session, now time.Time) Event {
+	return newEvent(SessionCreated, s, now, s.Label()+" started in "+s.WorkDir)
+}
+
+// Killed returns the event for a session that is gone.
+func Killed(s session.Session, now time.Time) Event {
+	return newEvent(SessionKilled, s, now, s.Label()+" ended")
+}
+
+// AutoForwarded returns the event for an autoforward sent to s.
+func AutoForwarded(s session.Session, now time.Time) Event {
+	return newEvent(AutoForwardSent, s, now, "autoforwarded "+s.Label())
+}
+
+// Transition returns the events for a status change: status_changed, and
+// task_done or permission_requested when the session changed to those.
+func Transition(t session.Transition, now time.Time) []Event {
+	ev := newEvent(StatusChanged, t.Session, now, t.Message())
+	ev.Previous = t.Previous.String()
+	if t.Session.Prompt != nil {
+		ev.Prompt = t.Session.Prompt.Summary()
+	}
+	events := []Event{ev}
+	switch t.Session.Status {
+	case session.TaskDone:
+		ev.Event = TaskDone
+		events = append(events, ev)
+	case session.Permission:
+		ev.Event = PermissionRequested
+		events = append(events, ev)
+	}
+	return events
+}
+
+func newEvent(typ string, s session.Session, now time.Time, text string) Event {
+	return Event{Event: typ, Time: now.UTC(), Text: text, Session: session.NewEntry(s)}
+}
+
+// Sender delivers events to the configured targets.
+type Sender struct {
+	// Backoff is the wait before the first retry; it doubles for each
+	// retry after that.
+	Backoff time.Duration
+
+	targets map[string]config.WebhookConfig
+	client  *http.Client
+}
+
+// New validates the targets and returns a sender for them.
+func New(targets map[string]config.WebhookConfig) (*Sender, error) {
+	for name, t := range targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("webhook %q: url is required", name)
+		}
+		for _, e := range t.Events {
+			if !slices.Contains(EventTypes, e) {
+				return nil, fmt.Errorf("webhook %q: unknown event %q", name, e)
+			}
+		}
+	}
+	return &Sender{Backoff: time.Second, targets: targets, client: &http.Client{}}, nil
+}
+
+// Load returns a sender for the webhooks in the config file. A broken
+// webhooks section is reported along with a sender that sends nothing.
+func Load() (*Sender, error) {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil {
+		return &Sender{}, err
+	}
+	s, err := New(cfg.Webhooks)
+	if err != nil {
+		return &Sender{}, err
+	}
+	return s, nil
+}
+
+// Enabled reports whether any target is configured.
+func (s *Sender) Enabled() bool {
+	return len(s.targets) > 0
+}
+
+// Targets returns the names of the configured targets, sorted.
+func (s *Sender) Targets() []string {
+	names := make([]string, 0, len(s.targets))
+	for name := range s.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send delivers ev to every target subscribed to its type, in parallel,
+// retrying each as configured. Returns the errors of targets that still
+// failed.
+func (s *Sender) Send(ctx context.Context, ev Event) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for name, t := range s.targets {
+		if len(t.Events) > 0 && !slices.Contains(t.Events, ev.Event) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.deliver(ctx, t, ev); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("webhook %s: %w", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// SendTo delivers ev to the named target whatever its events, as
+// `crabctl webhook test` does.
+func (s *Sender) SendTo(ctx context.Context, name string, ev Event) error {
+	t, ok := s.targets[name]
+	if !ok {
+		return fmt.Errorf("no webhook %q", name)
+	}
+	return s.deliver(ctx, t, ev)
+}
+
+// queueSize is how many events a Queue holds while its targets are slow or
+// retrying before it starts dropping them.
+const queueSize = 256
+
+// Queue sends events through a Sender from a single goroutine, in the order
+// they were added, so a retrying target delays later events rather than
+// being overtaken by them.
+type Queue struct {
+	sender *Sender
+	onErr  func(Event, error)
+	events chan Event
+	start  sync.Once
+}
+
+// NewQueue returns a queue sending through s. onErr is called for each event
+// that failed on some target or didn't fit in the queue; later events are
+// still sent.
+func NewQueue(s *Sender, onErr func(Event, error)) *Queue {
+	return &Queue{sender: s, onErr: onErr, events: make(chan Event, queueSize)}
+}
+
+// Add queues events for delivery without waiting for them to be sent.
+func (q *Queue) Add(events ...Event) {
+	if len(events) == 0 || !q.sender.Enabled() {
+		return
+	}
+	q.start.Do(func() { go q.run() })
+	for _, ev := range events {
+		select {
+		case q.events <- ev:
+		default:
+			q.onErr(ev, errors.New("queue full, event dropped"))
+		}
+	}
+}
+
+func (q *Queue) run() {
+	for ev := range q.events {
+		if err := q.sender.Send(context.Background(), ev); err != nil {
+			q.onErr(ev, err)
+		}
+	}
+}
+
+// deliver posts ev to t, retrying network errors, 429s and 5xx responses
+// with exponential backoff.
+func (s *Sender) deliver(ctx context.Context, t config.WebhookConfig, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	retries := defaultRetries
+	if t.Retries != nil {
+		retries = *t.Retries
+	}
+	wait := s.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, t, ev.Event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= retries {
+			if attempt > 0 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes one delivery attempt. retry reports whether a failure is
+// worth retrying.
+func (s *Sender) post(ctx context.Context, t config.WebhookConfig, event string, body []byte) (retry bool, err error) {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crabctl")
+	req.Header.Set("X-Crabctl-Event", event)
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	if t.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(t.Secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("%s returned %s", t.URL, resp.Status)
+}
+
+// Sign returns the signature header value for a delivery of body at
+// timestamp (as in TimestampHeader): "sha256=" and the hex HMAC-SHA256 of
+// timestamp + "." + body keyed with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
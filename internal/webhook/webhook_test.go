@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simon/crabctl/internal/config"
+	"github.com/simon/crabctl/internal/session"
+)
+
+// standIn records deliveries and answers with the next status in codes,
+// then 200.
+type standIn struct {
+	mu     sync.Mutex
+	codes  []int
+	bodies []string
+	sigs   []string
+	stamps []string
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, string(body))
+	s.sigs = append(s.sigs, r.Header.Get(SignatureHeader))
+	s.stamps = append(s.stamps, r.Header.Get(TimestampHeader))
+	code := http.StatusOK
+	if len(s.codes) > 0 {
+		code, s.codes = s.codes[0], s.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func TestSend(t *testing.T) {
+	tr := session.Transition{
+		Session:  session.Session{Name: "fix", FullName: "crab-fix", Status: session.TaskDone},
+		Previous: session.Running,
+	}
+	events := Transition(tr, time.Now())
+	if len(events) != 2 || events[0].Event != StatusChanged || events[1].Event != TaskDone {
+		t.Fatalf("Transition() = %+v", events)
+	}
+
+	one := 1
+	tests := []struct {
+		name     string
+		cfg      config.WebhookConfig
+		codes    []int
+		ev       Event
+		attempts int
+		err      bool
+	}{
+		{name: "delivered", ev: events[0], attempts: 1},
+		{name: "retried 5xx", codes: []int{500, 503}, ev: events[0], attempts: 3},
+		{name: "gives up", cfg: config.WebhookConfig{Retries: &one}, codes: []int{500, 500, 500}, ev: events[0], attempts: 2, err: true},
+		{name: "no retry on 4xx", codes: []int{400}, ev: events[0], attempts: 1, err: true},
+		{name: "filtered out", cfg: config.WebhookConfig{Events: []string{TaskDone}}, ev: events[0], attempts: 0},
+		{name: "subscribed", cfg: config.WebhookConfig{Events: []string{TaskDone}}, ev: events[1], attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stand := &standIn{codes: tt.codes}
+			srv := httptest.NewServer(stand)
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.URL = srv.URL
+			cfg.Secret = "sekret"
+			s, err := New(map[string]config.WebhookConfig{"team": cfg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Backoff = time.Millisecond
+			err = s.Send(context.Background(), tt.ev)
+			if (err != nil) != tt.err {
+				t.Errorf("err = %v, want error %v", err, tt.err)
+			}
+			if len(stand.bodies) != tt.attempts {
+				t.Fatalf("got %d attempts, want %d", len(stand.bodies), tt.attempts)
+			}
+			if tt.attempts == 0 {
+				return
+			}
+			stamp, err := strconv.ParseInt(stand.stamps[0], 10, 64)
+			if err != nil || time.Since(time.Unix(stamp, 0)) > time.Minute {
+				t.Errorf("timestamp = %q, want the time of delivery", stand.stamps[0])
+			}
+			if want := Sign("sekret", stand.stamps[0], []byte(stand.bodies[0])); stand.sigs[0] != want {
+				t.Errorf("signature = %q, want %q", stand.sigs[0], want)
+			}
+			if stand.sigs[0] == Sign("sekret", "0", []byte(stand.bodies[0])) {
+				t.Error("signature doesn't cover the timestamp")
+			}
+			var got Event
+			if err := json.Unmarshal([]byte(stand.bodies[0]), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Event != tt.ev.Event || got.Session.Name != "fix" || !strings.Contains(got.Text, "fix is done") {
+				t.Errorf("posted %+v", got)
+			}
+		})
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	if _, err := New(map[string]config.WebhookConfig{"x": {}}); err == nil {
+		t.Error("target without url accepted")
+	}
+	if _, err := New(map[string]config.WebhookConfig{"x": {URL: "http://x", Events: []string{"exploded"}}}); err == nil {
+		t.Error("unknown event accepted")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	stand := &standIn{codes: []int{400}}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+	s, err := New(map[string]config.WebhookConfig{"team": {URL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := make(chan Event, 3)
+	q := NewQueue(s, func(ev Event, err error) { failed <- ev })
+	sess := session.Session{Name: "fix", FullName: "crab-fix"}
+	now := time.Now()
+	q.Add(Created(sess, now), AutoForwarded(sess, now), Killed(sess, now))
+
+	// The rejected first event doesn't stop the ones after it
+	if ev := <-failed; ev.Event != SessionCreated {
+		t.Errorf("failed event = %s, want %s", ev.Event, SessionCreated)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stand.mu.Lock()
+		bodies := append([]string(nil), stand.bodies...)
+		stand.mu.Unlock()
+		if len(bodies) == 3 {
+			for i, want := range []string{SessionCreated, AutoForwardSent, SessionKilled} {
+				var got Event
+				_ = json.Unmarshal([]byte(bodies[i]), &got)
+				if got.Event != want {
+					t.Errorf("delivery %d = %s, want %s", i, got.Event, want)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d deliveries, want 3", len(bodies))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}